@@ -1,12 +1,21 @@
 package configue
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
+	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/negrel/configue/env"
 	"github.com/negrel/configue/ini"
@@ -24,6 +33,13 @@ type Backend interface {
 	SetOutput(io.Writer)
 }
 
+// Bootstrapper is implemented by backends able to resolve a subset of options
+// before any backend is parsed. See [Figue.BootstrapVar].
+type Bootstrapper interface {
+	Backend
+	Bootstrap(names ...string) error
+}
+
 type Value interface {
 	fmt.Stringer
 	Set(string) error
@@ -31,7 +47,15 @@ type Value interface {
 
 var _ Backend = &Env{}
 var _ Backend = &Flag{}
+var _ Bootstrapper = &Env{}
+var _ Bootstrapper = &Flag{}
 var _ Backend = &Ini{}
+var _ Backend = &IniDir{}
+var _ Backend = &IniFiles{}
+var _ Backend = &IniWalk{}
+var _ Backend = &SystemdCredentials{}
+var _ Backend = &Dir{}
+var _ Backend = &HTTP{}
 
 // Env defines an environment variables based backend.
 type Env struct {
@@ -80,11 +104,55 @@ func (env *Env) Set(name, value string) error {
 	return env.EnvSet.Set(envName, value)
 }
 
+// setDefValue updates default value of named option.
+func (env *Env) setDefValue(name, defValue string) {
+	if envVar := env.Lookup(env.envName(name)); envVar != nil {
+		envVar.DefValue = defValue
+	}
+}
+
+// SecretFiles enables reading value of options at paths from files: if env
+// var PREFIX_NAME_FILE is set, value of PREFIX_NAME is read from file at path
+// PREFIX_NAME_FILE. If no path is provided, it is enabled for all options. See
+// [env.EnvSet.SecretFiles].
+func (env *Env) SecretFiles(paths ...string) {
+	if len(paths) == 0 {
+		env.EnvSet.SecretFiles()
+		return
+	}
+
+	for _, path := range paths {
+		env.EnvSet.SecretFiles(env.envName(path))
+	}
+}
+
+// SecretCommands enables reading value of options at paths from the output of
+// a command set in env var PREFIX_NAME_CMD. See [env.EnvSet.SecretCommands].
+func (env *Env) SecretCommands(paths ...string) {
+	for _, path := range paths {
+		env.EnvSet.SecretCommands(env.envName(path))
+	}
+}
+
 // Parse implements Backend by parsing os.Environ().
 func (env *Env) Parse() error {
 	return env.EnvSet.Parse(os.Environ())
 }
 
+// Bootstrap implements Bootstrapper by setting named options from os.Environ().
+func (env *Env) Bootstrap(names ...string) error {
+	for _, name := range names {
+		envName := env.envName(name)
+		if value, ok := os.LookupEnv(envName); ok {
+			err := env.EnvSet.Set(envName, value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for env var %s: %v", value, envName, err)
+			}
+		}
+	}
+	return nil
+}
+
 // Visit implements Backend.
 func (eb *Env) Visit(fn func(option.Option)) {
 	eb.EnvSet.Visit(func(envVar *env.EnvVar) {
@@ -142,11 +210,63 @@ func (flag *Flag) Set(name, value string) error {
 	return flag.FlagSet.Set(flagName, value)
 }
 
+// setDefValue updates default value of named option.
+func (flag *Flag) setDefValue(name, defValue string) {
+	if f := flag.Lookup(flag.flagName(name)); f != nil {
+		f.DefValue = defValue
+	}
+}
+
 // Parse implements Backend by parsing flags from os.Args[1:].
 func (flag *Flag) Parse() error {
 	return flag.FlagSet.Parse(os.Args[1:])
 }
 
+// Bootstrap implements Bootstrapper by setting named options from
+// os.Args[1:]. Like [flag.FlagSet.Parse], scanning stops at the first
+// non-flag argument, at the terminator "--" or at the first undefined flag.
+// Other flags are left untouched.
+func (flag *Flag) Bootstrap(names ...string) error {
+	flagNames := make(map[string]bool, len(names))
+	for _, name := range names {
+		flagNames[flag.flagName(name)] = true
+	}
+
+	args := os.Args[1:]
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) < 2 || arg[0] != '-' || arg == "--" {
+			break
+		}
+
+		name := strings.TrimPrefix(arg[1:], "-")
+		name, value, hasValue := strings.Cut(name, "=")
+		f := flag.Lookup(name)
+		if f == nil {
+			break
+		}
+		if !hasValue {
+			if fv, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && fv.IsBoolFlag() {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			} else {
+				break
+			}
+		}
+
+		if flagNames[name] {
+			err := flag.FlagSet.Set(name, value)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for flag -%s: %v", value, name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
 // Visit visits the flags in lexicographical order, calling fn for each. It
 // visits only those flags that have been set.
 func (fb *Flag) Visit(fn func(option.Option)) {
@@ -168,63 +288,263 @@ func (fb *Flag) VisitAll(fn func(option.Option)) {
 }
 
 // PrintDefaults implements Backend.
-func (flag *Flag) PrintDefaults() {
-	if name := flag.Name(); name != "" {
-		_, _ = fmt.Fprintf(flag.Output(), "Flags of %v:\n", name)
+func (fb *Flag) PrintDefaults() {
+	if name := fb.Name(); name != "" {
+		_, _ = fmt.Fprintf(fb.Output(), "Flags of %v:\n", name)
 	} else {
-		_, _ = fmt.Fprintln(flag.Output(), "Flags:")
+		_, _ = fmt.Fprintln(fb.Output(), "Flags:")
 	}
-	flag.FlagSet.PrintDefaults()
+
+	// Temporarily unwrap values so flag package can guess their type and zero
+	// value.
+	wrapped := make(map[*flag.Flag]Value)
+	fb.FlagSet.VisitAll(func(f *flag.Flag) {
+		if unwrapped := option.Unwrap(f.Value); unwrapped != f.Value {
+			wrapped[f] = f.Value
+			f.Value = unwrapped
+		}
+	})
+	defer func() {
+		for f, val := range wrapped {
+			f.Value = val
+		}
+	}()
+
+	fb.FlagSet.PrintDefaults()
 }
 
-// Ini defines an INI file based Backend implementation.
-type Ini struct {
+// MissingFilePolicy defines how file based backends behave if configuration
+// file doesn't exist.
+type MissingFilePolicy int
+
+const (
+	// IgnoreMissingFile parses nothing if file doesn't exist.
+	IgnoreMissingFile MissingFilePolicy = iota
+	// ErrorOnMissingFile returns an error if file doesn't exist.
+	ErrorOnMissingFile
+	// ErrorOnMissingExplicitFile returns an error if file doesn't exist and
+	// its path was set explicitly (e.g. using a -config flag), that is, it
+	// differs from the one provided to the constructor.
+	ErrorOnMissingExplicitFile
+)
+
+// iniSet defines methods shared by INI file based backends.
+type iniSet struct {
 	*ini.PropSet
-	FilePath string
 }
 
-// NewINI returns a new INI based backend that will parse data from provided
-// filepath. If the file doesn't exist, this backend will parse nothing.
-func NewINI(fpath string) *Ini {
-	ib := &Ini{ini.NewPropSet("", ContinueOnError), fpath}
-	ib.Usage = func() {}
-	return ib
+func newIniSet() iniSet {
+	ps := ini.NewPropSet("", ContinueOnError)
+	ps.Usage = func() {}
+	return iniSet{ps}
 }
 
 // Init implements Backend.
-func (ini *Ini) Init(name string) {
-	ini.PropSet.Init(name, ContinueOnError)
+func (is iniSet) Init(name string) {
+	is.PropSet.Init(name, ContinueOnError)
 }
 
 // Var implements Backend.
-func (ini *Ini) Var(val Value, name, usage string) string {
-	ini.PropSet.Var(val, name, usage)
+func (is iniSet) Var(val Value, name, usage string) string {
+	is.PropSet.Var(val, name, usage)
 	return name
 }
 
 // Set sets the value of the named command-line option.
-func (ini *Ini) Set(name, value string) error {
-	return ini.PropSet.Set(name, value)
+func (is iniSet) Set(name, value string) error {
+	return is.PropSet.Set(name, value)
+}
+
+// Visit implements Backend.
+func (is iniSet) Visit(fn func(option.Option)) {
+	is.PropSet.Visit(func(prop *ini.Property) {
+		fn(*prop)
+	})
+}
+
+// VerifySignatures enables verification of detached signatures of
+// configuration files and included files. See [Figue.VerifySignatures].
+func (is iniSet) VerifySignatures(keys ...ed25519.PublicKey) {
+	is.PropSet.Verify = func(name string, content []byte) error {
+		return verifySignature(nil, keys, name, content)
+	}
+}
+
+// Ini defines an INI file based Backend implementation.
+type Ini struct {
+	iniSet
+	// FilePath is the path of the configuration file. "-" stands for standard
+	// input.
+	FilePath string
+	// CreateIfMissing enables creation of a configuration file containing
+	// default values if FilePath doesn't exist. Parent directories are created
+	// if needed.
+	CreateIfMissing bool
+	// FS, if non-nil, is the file system FilePath and included files are read
+	// from. FilePath must then be a valid path as defined by [fs.ValidPath].
+	FS fs.FS
+	// Open, if non-nil, is called to open configuration file instead of
+	// reading FilePath.
+	Open func() (io.ReadCloser, error)
+	// MissingFile defines how Parse behaves if configuration file doesn't
+	// exist.
+	MissingFile MissingFilePolicy
+	// Policy, if non-nil, defines checks performed on configuration file and
+	// included files read from the host file system.
+	Policy *FilePolicy
+
+	defaultFilePath string
+}
+
+// stdinName is the name of standard input in errors.
+const stdinName = "<stdin>"
+
+// NewINI returns a new INI based backend that will parse data from provided
+// filepath. If the file doesn't exist, this backend will parse nothing unless
+// MissingFile policy is changed.
+func NewINI(fpath string) *Ini {
+	return &Ini{
+		iniSet:          newIniSet(),
+		FilePath:        fpath,
+		defaultFilePath: fpath,
+	}
+}
+
+// NewINIFS returns a new INI based backend that will parse data from file at
+// fpath in fsys (e.g. an embed.FS).
+func NewINIFS(fsys fs.FS, fpath string) *Ini {
+	ib := NewINI(fpath)
+	ib.FS = fsys
+	return ib
 }
 
 // Parse implements Backend.
 func (ini *Ini) Parse() error {
-	f, err := os.Open(ini.FilePath)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
+	r, err := ini.open()
+	if errors.Is(err, fs.ErrNotExist) {
+		switch {
+		case ini.MissingFile == ErrorOnMissingFile,
+			ini.MissingFile == ErrorOnMissingExplicitFile &&
+				ini.FilePath != ini.defaultFilePath:
+			return err
+
+		case ini.CreateIfMissing && ini.FS == nil && ini.Open == nil:
+			return ini.create()
+
+		default:
 			return nil
 		}
+	}
+	if err != nil {
 		return err
 	}
 
-	return errors.Join(ini.PropSet.Parse(f), f.Close())
+	ini.PropSet.FS = ini.FS
+	ini.PropSet.OpenFile = ini.Policy.opener()
+	err = errors.Join(ini.PropSet.Parse(r), r.Close())
+	if err != nil {
+		return err
+	}
+
+	// Files of FS aren't host files whose permissions can be checked.
+	if ini.FS == nil {
+		ini.Policy.warnReadableSecrets(ini.PropSet)
+	}
+	return nil
 }
 
-// Visit implements Backend.
-func (ib *Ini) Visit(fn func(option.Option)) {
-	ib.PropSet.Visit(func(prop *ini.Property) {
-		fn(*prop)
-	})
+// VerifySignatures enables verification of detached signatures of
+// configuration file and included files read from FS, if set, or the host file
+// system. See [Figue.VerifySignatures].
+func (ini *Ini) VerifySignatures(keys ...ed25519.PublicKey) {
+	ini.PropSet.Verify = func(name string, content []byte) error {
+		return verifySignature(ini.FS, keys, name, content)
+	}
+}
+
+// open opens configuration file.
+func (ini *Ini) open() (io.ReadCloser, error) {
+	switch {
+	case ini.Open != nil:
+		return ini.Open()
+	case ini.FilePath == "-":
+		return namedReader{io.NopCloser(os.Stdin), stdinName}, nil
+	case ini.FS != nil:
+		f, err := ini.FS.Open(ini.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		return namedReader{f, ini.FilePath}, nil
+	default:
+		return ini.Policy.open(ini.FilePath)
+	}
+}
+
+// namedReader is an io.ReadCloser with a name used in INI errors.
+type namedReader struct {
+	io.ReadCloser
+	name string
+}
+
+// Name returns name of the reader.
+func (nr namedReader) Name() string {
+	return nr.name
+}
+
+// parseINIFile parses INI file at fpath using provided property set. Files
+// are checked against policy if it isn't nil.
+func parseINIFile(ps *ini.PropSet, policy *FilePolicy, fpath string) error {
+	f, err := policy.open(fpath)
+	if err != nil {
+		return err
+	}
+
+	return parseINI(ps, policy, f)
+}
+
+// parseINI parses and closes opened INI file f using provided property set.
+// Included files are checked against policy if it isn't nil.
+func parseINI(ps *ini.PropSet, policy *FilePolicy, f io.ReadCloser) error {
+	ps.OpenFile = policy.opener()
+	return errors.Join(ps.Parse(f), f.Close())
+}
+
+// printProfiles prints, to property set output, profiles found in parsed
+// configuration files.
+func printProfiles(ps *ini.PropSet) {
+	profiles := ps.AvailableProfiles()
+	if len(profiles) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(ps.Output(), "Available profiles: %v\n", strings.Join(profiles, ", "))
+}
+
+// create creates configuration file at ini.FilePath and writes default values
+// of all defined properties to it. Created directories and file are only
+// accessible by current user. Partially written file is removed on failure.
+func (ini *Ini) create() error {
+	err := os.MkdirAll(filepath.Dir(ini.FilePath), 0o700)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(ini.FilePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	err = ini.PropSet.WriteDefaults(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(ini.FilePath)
+		return fmt.Errorf("failed to write configuration file: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(ini.Output(), "Configuration file created at %v\n", ini.FilePath)
+	return nil
 }
 
 // PrintDefaults implements Backend. Unlike other backends, we only print path
@@ -239,4 +559,845 @@ func (ini *Ini) PrintDefaults() {
 	} else {
 		_, _ = fmt.Fprintf(ini.Output(), "Configuration file is located at %v\n", ini.FilePath)
 	}
+	printProfiles(ini.PropSet)
+}
+
+// IniDir defines a Backend implementation that parses all INI files of a
+// drop-in directory (e.g. /etc/myapp/conf.d/*.ini).
+type IniDir struct {
+	iniSet
+	Dir     string
+	Pattern string
+	// Policy, if non-nil, defines checks performed on configuration files and
+	// included files.
+	Policy *FilePolicy
+}
+
+// NewINIDir returns a new INI based backend that will parse, in lexical order,
+// all files of directory dir matching pattern (see [filepath.Match]). Options
+// of a file overrides options of files parsed before it. If directory doesn't
+// exist, this backend will parse nothing.
+func NewINIDir(dir, pattern string) *IniDir {
+	return &IniDir{iniSet: newIniSet(), Dir: dir, Pattern: pattern}
+}
+
+// Files returns path of files parsed by this backend in lexical order.
+func (ini *IniDir) Files() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(ini.Dir, ini.Pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	files := matches[:0]
+	for _, fpath := range matches {
+		stat, err := os.Stat(fpath)
+		if err != nil {
+			return nil, err
+		}
+		if !stat.IsDir() {
+			files = append(files, fpath)
+		}
+	}
+	slices.Sort(files)
+
+	return files, nil
+}
+
+// Parse implements Backend.
+func (ini *IniDir) Parse() error {
+	files, err := ini.Files()
+	if err != nil {
+		return err
+	}
+
+	for _, fpath := range files {
+		err := parseINIFile(ini.PropSet, ini.Policy, fpath)
+		if err != nil {
+			return err
+		}
+	}
+
+	ini.Policy.warnReadableSecrets(ini.PropSet)
+	return nil
+}
+
+// PrintDefaults implements Backend. Like Ini backend, we only print path
+// to configuration files here.
+func (ini *IniDir) PrintDefaults() {
+	if ini.Dir == "" {
+		return
+	}
+
+	fpath := filepath.Join(ini.Dir, ini.Pattern)
+	if name := ini.Name(); name != "" {
+		_, _ = fmt.Fprintf(ini.Output(), "Configuration files of %v are located at %v\n", name, fpath)
+	} else {
+		_, _ = fmt.Fprintf(ini.Output(), "Configuration files are located at %v\n", fpath)
+	}
+	printProfiles(ini.PropSet)
+}
+
+// IniFiles defines a Backend implementation that parses a list of layered INI
+// files (e.g. system, user and project configuration files).
+type IniFiles struct {
+	iniSet
+	FilePaths []string
+	// Policy, if non-nil, defines checks performed on configuration files and
+	// included files.
+	Policy *FilePolicy
+	loaded map[string]bool
+}
+
+// NewINIFiles returns a new INI based backend that will parse, in order, all
+// existing files among provided file paths. Options of a file overrides options
+// of files parsed before it. Missing files are ignored.
+func NewINIFiles(fpaths ...string) *IniFiles {
+	return &IniFiles{iniSet: newIniSet(), FilePaths: fpaths}
+}
+
+// Parse implements Backend.
+func (ini *IniFiles) Parse() error {
+	ini.loaded = make(map[string]bool)
+
+	for _, fpath := range ini.FilePaths {
+		// Only missing files are ignored, not missing included files.
+		f, err := ini.Policy.open(fpath)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = parseINI(ini.PropSet, ini.Policy, f)
+		if err != nil {
+			return err
+		}
+		ini.loaded[fpath] = true
+	}
+
+	ini.Policy.warnReadableSecrets(ini.PropSet)
+	return nil
+}
+
+// Loaded returns path of files that were parsed by last call to Parse.
+func (ini *IniFiles) Loaded() []string {
+	var loaded []string
+	for _, fpath := range ini.FilePaths {
+		if ini.loaded[fpath] {
+			loaded = append(loaded, fpath)
+		}
+	}
+	return loaded
+}
+
+// PrintDefaults implements Backend. Like Ini backend, we only print path
+// to configuration files here. Files are listed in parsing order and, once
+// parsed, loaded files are marked as such.
+func (ini *IniFiles) PrintDefaults() {
+	if len(ini.FilePaths) == 0 {
+		return
+	}
+
+	if name := ini.Name(); name != "" {
+		_, _ = fmt.Fprintf(ini.Output(), "Configuration files of %v (later files override earlier ones):\n", name)
+	} else {
+		_, _ = fmt.Fprintln(ini.Output(), "Configuration files (later files override earlier ones):")
+	}
+	for _, fpath := range ini.FilePaths {
+		if ini.loaded[fpath] {
+			_, _ = fmt.Fprintf(ini.Output(), "  %v (loaded)\n", fpath)
+		} else {
+			_, _ = fmt.Fprintf(ini.Output(), "  %v\n", fpath)
+		}
+	}
+	printProfiles(ini.PropSet)
+}
+
+// IniWalk defines a Backend implementation that parses INI files named FileName
+// in directory Dir and its parents, similar to editorconfig. Files nearest to
+// Dir override farther ones.
+type IniWalk struct {
+	iniSet
+	Dir      string
+	FileName string
+	// StopAt contains names of files or directories (e.g. ".git") marking the
+	// last directory to search. Search also stops at user home directory and
+	// in directories containing a file with a top-level "root = true" property,
+	// unless an option is named root.
+	StopAt []string
+	// Policy, if non-nil, defines checks performed on configuration files and
+	// included files.
+	Policy *FilePolicy
+	loaded []string
+}
+
+// NewINIWalk returns a new INI based backend that will parse files named fname
+// in directory dir and its parents up to repository root (a directory
+// containing .git), user home directory or a file containing "root = true".
+func NewINIWalk(dir, fname string) *IniWalk {
+	return &IniWalk{
+		iniSet:   newIniSet(),
+		Dir:      dir,
+		FileName: fname,
+		StopAt:   []string{".git"},
+	}
+}
+
+// hasRootMarker reports whether top-level "root" properties mark the last
+// file to parse, that is, if no option is named root.
+func (ini *IniWalk) hasRootMarker() bool {
+	return ini.Lookup("root") == nil
+}
+
+// Files returns path of files that will be parsed by this backend, ordered
+// from nearest to farthest.
+func (ini *IniWalk) Files() ([]string, error) {
+	dir, err := filepath.Abs(ini.Dir)
+	if err != nil {
+		return nil, err
+	}
+	home, _ := os.UserHomeDir()
+
+	var files []string
+	for {
+		fpath := filepath.Join(dir, ini.FileName)
+		stat, err := os.Stat(fpath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		if err == nil && !stat.IsDir() {
+			files = append(files, fpath)
+
+			if ini.hasRootMarker() {
+				root, err := isRootINIFile(ini.Policy, fpath)
+				if err != nil {
+					return nil, err
+				}
+				if root {
+					break
+				}
+			}
+		}
+
+		if dir == home || ini.isBoundary(dir) {
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return files, nil
+}
+
+// isBoundary reports whether dir contains one of ini.StopAt files.
+func (ini *IniWalk) isBoundary(dir string) bool {
+	for _, name := range ini.StopAt {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isRootINIFile reports whether INI file at fpath contains a top-level
+// "root = true" property. File is checked against policy if it isn't nil.
+func isRootINIFile(policy *FilePolicy, fpath string) (bool, error) {
+	f, err := policy.open(fpath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	var key string
+	scanner := ini.NewScanner(f)
+	for scanner.Scan() {
+		tok := scanner.Token()
+		switch tok.Kind {
+		case ini.SectionHeader:
+			if tok.Text != "" {
+				return false, nil
+			}
+		case ini.Key:
+			key = tok.Text
+		case ini.Value:
+			if key == "root" {
+				root, _ := strconv.ParseBool(tok.Text)
+				return root, nil
+			}
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+// Parse implements Backend.
+func (ini *IniWalk) Parse() error {
+	files, err := ini.Files()
+	if err != nil {
+		return err
+	}
+	if ini.hasRootMarker() {
+		ini.Directive("root", func(value string) error {
+			_, err := strconv.ParseBool(value)
+			return err
+		})
+	}
+
+	ini.loaded = nil
+	for _, fpath := range slices.Backward(files) {
+		err := parseINIFile(ini.PropSet, ini.Policy, fpath)
+		if err != nil {
+			return err
+		}
+		ini.loaded = append(ini.loaded, fpath)
+	}
+
+	ini.Policy.warnReadableSecrets(ini.PropSet)
+	return nil
+}
+
+// Loaded returns path of files that were parsed by last call to Parse, in
+// parsing order.
+func (ini *IniWalk) Loaded() []string {
+	return slices.Clone(ini.loaded)
+}
+
+// PrintDefaults implements Backend. Like Ini backend, we only print path
+// to configuration files here.
+func (ini *IniWalk) PrintDefaults() {
+	if ini.FileName == "" {
+		return
+	}
+
+	if name := ini.Name(); name != "" {
+		_, _ = fmt.Fprintf(ini.Output(), "Configuration files of %v are named %v and searched in %v and its parent directories\n", name, ini.FileName, ini.Dir)
+	} else {
+		_, _ = fmt.Fprintf(ini.Output(), "Configuration files are named %v and searched in %v and its parent directories\n", ini.FileName, ini.Dir)
+	}
+	for _, fpath := range ini.loaded {
+		_, _ = fmt.Fprintf(ini.Output(), "  %v (loaded)\n", fpath)
+	}
+	printProfiles(ini.PropSet)
+}
+
+// optionSet defines options registry of backends that aren't built on a
+// flag-like set, such as those loading options from a directory of files.
+type optionSet struct {
+	name    string
+	kind    string
+	output  io.Writer
+	parsed  bool
+	options map[string]*option.Option
+	verify  func(name string, content []byte) error
+}
+
+func newOptionSet(kind string) optionSet {
+	return optionSet{
+		kind:    kind,
+		options: make(map[string]*option.Option),
+	}
+}
+
+// Init implements Backend.
+func (opts *optionSet) Init(name string) {
+	opts.name = name
+}
+
+// define defines option at path.
+func (opts *optionSet) define(val Value, path, usage string) {
+	if _, defined := opts.options[path]; defined {
+		panic(fmt.Sprintf("%v redefined: %s", opts.kind, path))
+	}
+
+	opts.options[path] = &option.Option{
+		Name:     path,
+		Usage:    usage,
+		Value:    val,
+		DefValue: val.String(),
+	}
+}
+
+// Lookup returns the Option of the named option, returning nil if none
+// exists.
+func (opts *optionSet) Lookup(name string) *option.Option {
+	return opts.options[name]
+}
+
+// Set sets the value of the named option.
+func (opts *optionSet) Set(name, value string) error {
+	opt, ok := opts.options[name]
+	if !ok {
+		return fmt.Errorf("no such %v %v", opts.kind, name)
+	}
+	return opt.Value.Set(value)
+}
+
+// Parsed implements Backend.
+func (opts *optionSet) Parsed() bool {
+	return opts.parsed
+}
+
+// Output returns the destination for usage and error messages. os.Stderr is
+// returned if output was not set or was set to nil.
+func (opts *optionSet) Output() io.Writer {
+	if opts.output == nil {
+		return os.Stderr
+	}
+	return opts.output
+}
+
+// SetOutput implements Backend.
+func (opts *optionSet) SetOutput(w io.Writer) {
+	opts.output = w
+}
+
+// parseDir sets options from files in dir using set. names returns names of
+// files loaded in option at path. Entries whose name starts with ".." are
+// ignored.
+func (opts *optionSet) parseDir(
+	dir string,
+	names func(path string) []string,
+	set func(val Value, fname, content string) error,
+) error {
+	opts.parsed = true
+
+	files := make(map[string]string)
+	for path := range opts.options {
+		for _, fname := range names(path) {
+			files[fname] = path
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %v directory: %w", opts.kind, err)
+	}
+
+	// Read and verify all files before setting any option.
+	loaded := make(map[string]string)
+	var fnames, contents []string
+	for _, entry := range entries {
+		fname := entry.Name()
+		if strings.HasPrefix(fname, "..") {
+			continue
+		}
+		path, ok := files[fname]
+		if !ok {
+			continue
+		}
+		if other, ok := loaded[path]; ok {
+			return fmt.Errorf("%vs %s and %s are both set", opts.kind, other, fname)
+		}
+		loaded[path] = fname
+
+		fpath := filepath.Join(dir, fname)
+		content, err := os.ReadFile(fpath)
+		if err != nil {
+			return fmt.Errorf("failed to read %v %s: %w", opts.kind, fname, err)
+		}
+		if opts.verify != nil {
+			err = opts.verify(fpath, content)
+			if err != nil {
+				return err
+			}
+		}
+
+		str := strings.TrimSuffix(string(content), "\n")
+		fnames = append(fnames, fname)
+		contents = append(contents, strings.TrimSuffix(str, "\r"))
+	}
+
+	for i, fname := range fnames {
+		err := set(opts.options[files[fname]].Value, fname, contents[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// printOptions prints names and usage of all options.
+func (opts *optionSet) printOptions(names func(path string) []string) {
+	paths := make([]string, 0, len(opts.options))
+	for path := range opts.options {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		opt := opts.options[path]
+		_, _ = fmt.Fprintf(opts.Output(), "  %v\n", strings.Join(names(path), ", "))
+		if opt.Usage != "" {
+			_, _ = fmt.Fprintf(opts.Output(), "    \t%v\n", strings.ReplaceAll(opt.Usage, "\n", "\n    \t"))
+		}
+	}
+}
+
+// SystemdCredentials defines a Backend implementation that loads options from
+// systemd credentials (see LoadCredential= in systemd.exec(5)) stored in
+// directory $CREDENTIALS_DIRECTORY. Credential named "db.password" or
+// "db_password" is loaded in option "db.password".
+type SystemdCredentials struct {
+	optionSet
+}
+
+// NewSystemdCredentials returns a new systemd credentials based backend. It
+// does nothing if $CREDENTIALS_DIRECTORY is unset.
+func NewSystemdCredentials() *SystemdCredentials {
+	return &SystemdCredentials{optionSet: newOptionSet("systemd credential")}
+}
+
+// credNames returns names of credentials loaded in option at path.
+func credNames(path string) []string {
+	names := []string{path}
+	if name := strings.ReplaceAll(path, ".", "_"); name != path {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Var implements Backend.
+func (sc *SystemdCredentials) Var(val Value, name, usage string) string {
+	sc.define(val, name, usage)
+	return name
+}
+
+// Parse implements Backend by loading credentials in $CREDENTIALS_DIRECTORY.
+func (sc *SystemdCredentials) Parse() error {
+	dir := os.Getenv("CREDENTIALS_DIRECTORY")
+	if dir == "" {
+		sc.parsed = true
+		return nil
+	}
+
+	return sc.parseDir(dir, credNames, func(val Value, cred, content string) error {
+		err := option.SetSecret(val, content)
+		if err != nil {
+			return fmt.Errorf("invalid value for systemd credential %s: %v", cred, err)
+		}
+		return nil
+	})
+}
+
+// PrintDefaults implements Backend. It prints names of credentials looked
+// up in $CREDENTIALS_DIRECTORY.
+func (sc *SystemdCredentials) PrintDefaults() {
+	if len(sc.options) == 0 {
+		return
+	}
+
+	dir := os.Getenv("CREDENTIALS_DIRECTORY")
+	if dir == "" {
+		dir = "unset"
+	}
+	if sc.name != "" {
+		_, _ = fmt.Fprintf(sc.Output(), "Systemd credentials of %v ($CREDENTIALS_DIRECTORY is %v):\n", sc.name, dir)
+	} else {
+		_, _ = fmt.Fprintf(sc.Output(), "Systemd credentials ($CREDENTIALS_DIRECTORY is %v):\n", dir)
+	}
+	sc.printOptions(credNames)
+}
+
+// Dir defines a Backend implementation that loads options from a directory
+// containing one file per option, such as Kubernetes ConfigMaps and Secrets
+// mounted as volumes. File "db.port" is loaded in option "db.port". Entries
+// whose name starts with ".." (e.g. "..data") are ignored and symbolic links
+// are followed.
+type Dir struct {
+	optionSet
+	// Path of the directory.
+	Path string
+	// Separator, if not empty, can be used in file names in place of dots in
+	// option path.
+	Separator string
+	// Secret marks values as secret (see [Figue.IsSecret]), as for mounted
+	// Kubernetes Secrets. Secret values are never interpolated.
+	Secret bool
+	data   string
+}
+
+// NewDir returns a new directory based backend loading options from files in
+// directory at path.
+func NewDir(path string) *Dir {
+	return &Dir{optionSet: newOptionSet("option file"), Path: path}
+}
+
+// fileNames returns names of files loaded in option at path.
+func (dir *Dir) fileNames(path string) []string {
+	names := []string{path}
+	if dir.Separator != "" && dir.Separator != "." {
+		if name := strings.ReplaceAll(path, ".", dir.Separator); name != path {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Var implements Backend.
+func (dir *Dir) Var(val Value, name, usage string) string {
+	dir.define(val, name, usage)
+	return name
+}
+
+// Parse implements Backend.
+func (dir *Dir) Parse() error {
+	dir.data, _ = os.Readlink(filepath.Join(dir.Path, "..data"))
+	return dir.parseDir(dir.Path, dir.fileNames, func(val Value, fname, content string) error {
+		if dir.Secret {
+			// Secret content isn't reported.
+			err := option.SetSecret(val, content)
+			if err != nil {
+				return fmt.Errorf("invalid value for option file %s: %v", fname, err)
+			}
+			return nil
+		}
+
+		err := val.Set(content)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for option file %s: %v", content, fname, err)
+		}
+		return nil
+	})
+}
+
+// VerifySignatures enables verification of detached signatures of option
+// files. See [Figue.VerifySignatures].
+func (dir *Dir) VerifySignatures(keys ...ed25519.PublicKey) {
+	dir.verify = func(name string, content []byte) error {
+		return verifySignature(nil, keys, name, content)
+	}
+}
+
+// Watch polls directory every interval until ctx is done and calls onChange
+// when its content is atomically swapped (i.e. when the target of its "..data"
+// symbolic link differs from the one of last parse, as with Kubernetes
+// volumes). onChange typically calls [Figue.Parse] to reload options. Options
+// whose file was removed keep their value. Watch returns ctx error.
+func (dir *Dir) Watch(ctx context.Context, interval time.Duration, onChange func()) error {
+	last := dir.data
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		target, _ := os.Readlink(filepath.Join(dir.Path, "..data"))
+		if target != last {
+			last = target
+			onChange()
+		}
+	}
+}
+
+// PrintDefaults implements Backend. It prints names of files looked up in
+// directory.
+func (dir *Dir) PrintDefaults() {
+	if len(dir.options) == 0 {
+		return
+	}
+
+	if dir.name != "" {
+		_, _ = fmt.Fprintf(dir.Output(), "Option files of %v are read from directory %v:\n", dir.name, dir.Path)
+	} else {
+		_, _ = fmt.Fprintf(dir.Output(), "Option files are read from directory %v:\n", dir.Path)
+	}
+	dir.printOptions(dir.fileNames)
+}
+
+// HTTPFailurePolicy defines how HTTP backend behaves if remote options can't
+// be fetched.
+type HTTPFailurePolicy int
+
+const (
+	// ErrorOnHTTPFailure returns an error if request fails, server responds
+	// with an unexpected status or an invalid document.
+	ErrorOnHTTPFailure HTTPFailurePolicy = iota
+	// UseCachedOnHTTPFailure applies values of the last successful response,
+	// if any, if request fails. Error is reported by [HTTP.LastError].
+	UseCachedOnHTTPFailure
+)
+
+// DefaultHTTPTimeout is the timeout of requests of HTTP backend if
+// [HTTPOptions.Timeout] is zero.
+const DefaultHTTPTimeout = 10 * time.Second
+
+// HTTPOptions defines options of HTTP backend.
+type HTTPOptions struct {
+	// Header contains headers sent with requests (e.g. Authorization).
+	Header http.Header
+	// Timeout is the timeout of requests. If zero, DefaultHTTPTimeout is used.
+	Timeout time.Duration
+	// TLSConfig, if non-nil, is the TLS configuration used by requests (e.g.
+	// custom root CAs or client certificates).
+	TLSConfig *tls.Config
+	// Client, if non-nil, is used to send requests instead of a client
+	// configured using Timeout and TLSConfig.
+	Client *http.Client
+	// FailurePolicy defines how Parse behaves if remote options can't be
+	// fetched.
+	FailurePolicy HTTPFailurePolicy
+	// IgnoreUndefined ignores remote options that aren't defined instead of
+	// returning an error.
+	IgnoreUndefined bool
+	// Context, if non-nil, is the context of requests. Cancelling it aborts
+	// pending and future calls to Parse.
+	Context context.Context
+}
+
+// HTTP defines a Backend implementation that fetches options from a JSON
+// document served over HTTP. Nested objects are flattened into option paths
+// like [FormatJSON] so both {"db": {"port": 5432}} and {"db.port": 5432} set
+// db.port. Responses' ETag is sent back using If-None-Match so unchanged
+// documents aren't transferred again when Parse is called periodically.
+type HTTP struct {
+	optionSet
+	URL     string
+	opts    HTTPOptions
+	client  *http.Client
+	etag    string
+	cached  []ini.KeyValue
+	changed bool
+	lastErr error
+}
+
+// NewHTTP returns a new HTTP based backend fetching options from url.
+func NewHTTP(url string, opts HTTPOptions) *HTTP {
+	client := opts.Client
+	if client == nil {
+		timeout := opts.Timeout
+		if timeout <= 0 {
+			timeout = DefaultHTTPTimeout
+		}
+		// http.DefaultTransport may have been replaced by another package.
+		transport := &http.Transport{}
+		if dt, ok := http.DefaultTransport.(*http.Transport); ok {
+			transport = dt.Clone()
+		}
+		transport.TLSClientConfig = opts.TLSConfig
+		client = &http.Client{Timeout: timeout, Transport: transport}
+	}
+
+	return &HTTP{
+		optionSet: newOptionSet("remote option"),
+		URL:       url,
+		opts:      opts,
+		client:    client,
+	}
+}
+
+// Var implements Backend.
+func (h *HTTP) Var(val Value, name, usage string) string {
+	h.define(val, name, usage)
+	return name
+}
+
+// Parse implements Backend by fetching remote options.
+func (h *HTTP) Parse() error {
+	h.parsed = true
+
+	kvs, err := h.fetch()
+	h.lastErr = err
+	if err != nil {
+		if h.opts.FailurePolicy != UseCachedOnHTTPFailure {
+			return err
+		}
+		kvs = h.cached
+	}
+
+	for _, kv := range kvs {
+		opt, ok := h.options[kv.Key]
+		if !ok {
+			if h.opts.IgnoreUndefined {
+				continue
+			}
+			return fmt.Errorf("remote option provided but not defined: %s", kv.Key)
+		}
+		err := opt.Value.Set(kv.Value)
+		if err != nil {
+			return fmt.Errorf("invalid value %q for remote option %s: %v", kv.Value, kv.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// fetch fetches and flattens remote document. Cached values are returned if
+// document didn't change since last fetch.
+func (h *HTTP) fetch() ([]ini.KeyValue, error) {
+	h.changed = false
+
+	ctx := h.opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch remote options: %w", err)
+	}
+	for key, values := range h.opts.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if h.etag != "" {
+		req.Header.Set("If-None-Match", h.etag)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch remote options: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && h.etag != "":
+		return h.cached, nil
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, fmt.Errorf("failed to fetch remote options from %v: unexpected status %v", h.URL, resp.Status)
+	}
+
+	kvs, err := readJSON(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote options document from %v: %w", h.URL, err)
+	}
+
+	h.etag = resp.Header.Get("ETag")
+	h.cached = kvs
+	h.changed = true
+	return kvs, nil
+}
+
+// Changed reports whether remote document changed during last call to Parse,
+// that is, it wasn't served from cache.
+func (h *HTTP) Changed() bool {
+	return h.changed
+}
+
+// LastError returns error of last call to Parse, including errors ignored by
+// UseCachedOnHTTPFailure policy.
+func (h *HTTP) LastError() error {
+	return h.lastErr
+}
+
+// PrintDefaults implements Backend. It prints paths of remote options.
+func (h *HTTP) PrintDefaults() {
+	if len(h.options) == 0 {
+		return
+	}
+
+	if h.name != "" {
+		_, _ = fmt.Fprintf(h.Output(), "Remote options of %v are fetched from %v:\n", h.name, h.URL)
+	} else {
+		_, _ = fmt.Fprintf(h.Output(), "Remote options are fetched from %v:\n", h.URL)
+	}
+	h.printOptions(func(path string) []string { return []string{path} })
 }
@@ -0,0 +1,1212 @@
+package configue
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// writeFiles writes files, whose names are relative to a new temporary
+// directory, and returns the directory. Parent directories are created as
+// needed and "<dir>" content creates a directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		fpath := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(fpath), 0o700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if content == "<dir>" {
+			err = os.Mkdir(fpath, 0o700)
+		} else {
+			err = os.WriteFile(fpath, []byte(content), 0o600)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestIni(t *testing.T) {
+	t.Run("CreateIfMissing", func(t *testing.T) {
+		t.Run("Disabled", func(t *testing.T) {
+			fpath := filepath.Join(t.TempDir(), "myapp", "config.ini")
+
+			ini := NewINI(fpath)
+			_ = ini.Int("int", 1, "an int option")
+
+			err := ini.Parse()
+			if err != nil {
+				t.Fatal("unexpected parse error", err)
+			}
+
+			_, err = os.Stat(fpath)
+			if !os.IsNotExist(err) {
+				t.Fatal("config file shouldn't be created", err)
+			}
+		})
+
+		t.Run("Enabled", func(t *testing.T) {
+			fpath := filepath.Join(t.TempDir(), "myapp", "config.ini")
+
+			var output strings.Builder
+			ini := NewINI(fpath)
+			ini.CreateIfMissing = true
+			ini.SetOutput(&output)
+			i := ini.Int("db.port", 5432, "database port")
+
+			err := ini.Parse()
+			if err != nil {
+				t.Fatal("unexpected parse error", err)
+			}
+			if !strings.Contains(output.String(), fpath) {
+				t.Fatal("created config file path isn't reported:", output.String())
+			}
+
+			stat, err := os.Stat(fpath)
+			if err != nil {
+				t.Fatal("config file not created", err)
+			}
+			if stat.Mode().Perm() != 0o600 {
+				t.Fatal("unexpected config file permissions", stat.Mode())
+			}
+			stat, err = os.Stat(filepath.Dir(fpath))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stat.Mode().Perm() != 0o700 {
+				t.Fatal("unexpected config directory permissions", stat.Mode())
+			}
+
+			content, err := os.ReadFile(fpath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != "[db]\n; database port\n; port = int\n  port = 5432\n\n" {
+				t.Fatal("unexpected config file content:", string(content))
+			}
+
+			// Created file is parsed on next run.
+			ini = NewINI(fpath)
+			ini.CreateIfMissing = true
+			ini.IntVar(i, "db.port", 0, "database port")
+			err = ini.Parse()
+			if err != nil {
+				t.Fatal("unexpected parse error", err)
+			}
+			if *i != 5432 {
+				t.Fatal("unexpected value", *i)
+			}
+		})
+
+		t.Run("ReadBack", func(t *testing.T) {
+			fpath := filepath.Join(t.TempDir(), "myapp", "config.ini")
+
+			newFigue := func() (*Figue, *string, *string, *string) {
+				ini := NewINI(fpath)
+				ini.CreateIfMissing = true
+				f := New("myapp", ContinueOnError, ini)
+				f.SetOutput(io.Discard)
+				cost := f.String("cost", "cost $$5", "price\nin dollars")
+				raw := f.String("raw", "a $$ b", "")
+				text := f.String("text", "line 1\nline 2", "")
+				f.DisableInterpolation("raw")
+				return f, cost, raw, text
+			}
+
+			for range 2 {
+				f, cost, raw, text := newFigue()
+				err := f.Parse()
+				if err != nil {
+					t.Fatal("unexpected parse error", err)
+				}
+				if *cost != "cost $$5" || *raw != "a $$ b" || *text != "line 1\nline 2" {
+					t.Fatalf("unexpected values %q %q %q", *cost, *raw, *text)
+				}
+			}
+		})
+	})
+}
+
+func TestIniSources(t *testing.T) {
+	t.Run("FS", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"conf/config.ini": {Data: []byte("a = 1\ninclude = base/b.ini")},
+			"conf/base/b.ini": {Data: []byte("b = 2")},
+		}
+
+		ini := NewINIFS(fsys, "conf/config.ini")
+		a := ini.Int("a", 0, "")
+		b := ini.Int("b", 0, "")
+
+		err := ini.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *a != 1 || *b != 2 {
+			t.Fatal("unexpected value", *a, *b)
+		}
+		loc, ok := ini.Provenance("b")
+		if !ok || loc.String() != "conf/base/b.ini:1" {
+			t.Fatal("unexpected provenance", loc)
+		}
+	})
+
+	t.Run("Open", func(t *testing.T) {
+		ini := NewINI("")
+		ini.Open = func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("a = 1")), nil
+		}
+		a := ini.Int("a", 0, "")
+
+		err := ini.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *a != 1 {
+			t.Fatal("unexpected value", *a)
+		}
+	})
+
+	t.Run("Stdin", func(t *testing.T) {
+		fpath := filepath.Join(t.TempDir(), "stdin")
+		err := os.WriteFile(fpath, []byte("a = abc"), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stdin, err := os.Open(fpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stdin.Close()
+		defer func(f *os.File) { os.Stdin = f }(os.Stdin)
+		os.Stdin = stdin
+
+		ini := NewINI("-")
+		ini.SetOutput(io.Discard)
+		_ = ini.Int("a", 0, "")
+
+		err = ini.Parse()
+		if err == nil || !strings.HasPrefix(err.Error(), "<stdin>:1:5: ") {
+			t.Fatal("error doesn't match expected:", err)
+		}
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		dir := t.TempDir()
+		defaultPath := filepath.Join(dir, "default.ini")
+		explicitPath := filepath.Join(dir, "explicit.ini")
+
+		for _, tcase := range []struct {
+			policy   MissingFilePolicy
+			fpath    string
+			expected bool
+		}{
+			{IgnoreMissingFile, defaultPath, false},
+			{IgnoreMissingFile, explicitPath, false},
+			{ErrorOnMissingFile, defaultPath, true},
+			{ErrorOnMissingFile, explicitPath, true},
+			{ErrorOnMissingExplicitFile, defaultPath, false},
+			{ErrorOnMissingExplicitFile, explicitPath, true},
+		} {
+			ini := NewINI(defaultPath)
+			ini.MissingFile = tcase.policy
+			ini.FilePath = tcase.fpath
+
+			err := ini.Parse()
+			if tcase.expected != (err != nil) {
+				t.Fatalf("unexpected error for policy %v and path %v: %v",
+					tcase.policy, tcase.fpath, err)
+			}
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				t.Fatal("error doesn't match expected:", err)
+			}
+		}
+	})
+}
+
+func TestIniDir(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"10-base.ini":     "[db]\nhost = localhost\nport = 1",
+			"20-override.ini": "[db]\nport = 2",
+			"30-ignored.bak":  "[db]\nport = 3",
+		})
+		err := os.Mkdir(filepath.Join(dir, "40-dir.ini"), 0o700)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ini := NewINIDir(dir, "*.ini")
+		host := ini.String("db.host", "", "database host")
+		port := ini.Int("db.port", 0, "database port")
+
+		err = ini.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *host != "localhost" || *port != 2 {
+			t.Fatal("unexpected value", *host, *port)
+		}
+
+		loc, ok := ini.Provenance("db.host")
+		if !ok || loc.String() != filepath.Join(dir, "10-base.ini")+":2" {
+			t.Fatal("unexpected provenance", loc)
+		}
+		loc, ok = ini.Provenance("db.port")
+		if !ok || loc.String() != filepath.Join(dir, "20-override.ini")+":2" {
+			t.Fatal("unexpected provenance", loc)
+		}
+	})
+
+	t.Run("MissingDir", func(t *testing.T) {
+		ini := NewINIDir(filepath.Join(t.TempDir(), "conf.d"), "*.ini")
+		err := ini.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("BrokenFragment", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"10-base.ini":   "[db]\nport = 1",
+			"20-broken.ini": "[db\nport = 2",
+		})
+
+		ini := NewINIDir(dir, "*.ini")
+		ini.SetOutput(io.Discard)
+		_ = ini.Int("db.port", 0, "database port")
+
+		err := ini.Parse()
+		if err == nil || err.Error() != filepath.Join(dir, "20-broken.ini")+":1:2: invalid section" {
+			t.Fatal("error doesn't match expected:", err)
+		}
+	})
+}
+
+func TestIniFiles(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"etc/config.ini":     "host = system\nport = 1\ndebug = false",
+		"project/.myapp.ini": "port = 3",
+	})
+	system := filepath.Join(dir, "etc", "config.ini")
+	user := filepath.Join(dir, "home", "config.ini")
+	project := filepath.Join(dir, "project", ".myapp.ini")
+
+	ini := NewINIFiles(system, user, project)
+	host := ini.String("host", "", "host")
+	port := ini.Int("port", 0, "port")
+	debug := ini.Bool("debug", true, "debug")
+
+	err := ini.Parse()
+	if err != nil {
+		t.Fatal("unexpected parse error", err)
+	}
+	if *host != "system" || *port != 3 || *debug {
+		t.Fatal("unexpected value", *host, *port, *debug)
+	}
+	if loaded := ini.Loaded(); len(loaded) != 2 || loaded[0] != system || loaded[1] != project {
+		t.Fatal("unexpected loaded files", loaded)
+	}
+
+	var output strings.Builder
+	ini.SetOutput(&output)
+	ini.PrintDefaults()
+	expected := "Configuration files (later files override earlier ones):\n" +
+		"  " + system + " (loaded)\n" +
+		"  " + user + "\n" +
+		"  " + project + " (loaded)\n"
+	if output.String() != expected {
+		t.Fatal("unexpected defaults", output.String())
+	}
+
+	t.Run("MissingInclude", func(t *testing.T) {
+		dir := writeFiles(t, map[string]string{
+			"config.ini": "host = included\ninclude = missing.ini\nport = 4",
+		})
+		fpath := filepath.Join(dir, "config.ini")
+
+		ini := NewINIFiles(fpath)
+		ini.SetOutput(io.Discard)
+		host := ini.String("host", "", "host")
+		_ = ini.Int("port", 0, "port")
+
+		err := ini.Parse()
+		if !errors.Is(err, os.ErrNotExist) || !strings.HasPrefix(err.Error(), fpath+":2:11: ") {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *host != "included" || len(ini.Loaded()) != 0 {
+			t.Fatal("unexpected state", *host, ini.Loaded())
+		}
+	})
+}
+
+func TestIniWalk(t *testing.T) {
+	setup := func(t *testing.T, files map[string]string) string {
+		dir := writeFiles(t, files)
+		// Don't search in home directory.
+		t.Setenv("HOME", dir)
+		return dir
+	}
+
+	t.Run("StopAtRepository", func(t *testing.T) {
+		dir := setup(t, map[string]string{
+			".myapprc.ini":                "a = 0\nb = 0\nc = 0",
+			"repo/.git":                   "<dir>",
+			"repo/.myapprc.ini":           "a = 1\nb = 1",
+			"repo/sub/.myapprc.ini":       "b = 2",
+			"repo/sub/deep/.myapprc.ini/": "<dir>",
+		})
+
+		ini := NewINIWalk(filepath.Join(dir, "repo", "sub", "deep"), ".myapprc.ini")
+		a := ini.Int("a", -1, "")
+		b := ini.Int("b", -1, "")
+		c := ini.Int("c", -1, "")
+
+		err := ini.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *a != 1 || *b != 2 || *c != -1 {
+			t.Fatal("unexpected value", *a, *b, *c)
+		}
+
+		loaded := ini.Loaded()
+		if len(loaded) != 2 ||
+			loaded[0] != filepath.Join(dir, "repo", ".myapprc.ini") ||
+			loaded[1] != filepath.Join(dir, "repo", "sub", ".myapprc.ini") {
+			t.Fatal("unexpected loaded files", loaded)
+		}
+		loc, ok := ini.Provenance("b")
+		if !ok || loc.Filename != loaded[1] {
+			t.Fatal("unexpected provenance", loc)
+		}
+	})
+
+	t.Run("RootMarker", func(t *testing.T) {
+		dir := setup(t, map[string]string{
+			".myapprc.ini":          "a = 0\nb = 0",
+			"sub/.myapprc.ini":      "root = true\na = 1\n[section]\nroot = false",
+			"sub/deep/.myapprc.ini": "root = false\nb = 2",
+		})
+
+		ini := NewINIWalk(filepath.Join(dir, "sub", "deep"), ".myapprc.ini")
+		a := ini.Int("a", -1, "")
+		b := ini.Int("b", -1, "")
+		_ = ini.Bool("section.root", false, "")
+
+		err := ini.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *a != 1 || *b != 2 {
+			t.Fatal("unexpected value", *a, *b)
+		}
+	})
+
+	t.Run("RootOption", func(t *testing.T) {
+		dir := setup(t, map[string]string{
+			".myapprc.ini":          "a = 1",
+			"sub/.myapprc.ini":      "root = /srv\nb = 2",
+			"sub/deep/.myapprc.ini": "root = true",
+		})
+
+		ini := NewINIWalk(filepath.Join(dir, "sub", "deep"), ".myapprc.ini")
+		a := ini.Int("a", -1, "")
+		b := ini.Int("b", -1, "")
+		root := ini.String("root", "", "")
+
+		err := ini.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *a != 1 || *b != 2 || *root != "true" {
+			t.Fatal("unexpected value", *a, *b, *root)
+		}
+	})
+
+	t.Run("StopAtHome", func(t *testing.T) {
+		dir := setup(t, map[string]string{
+			"home/.myapprc.ini":     "a = 1",
+			"home/sub/.myapprc.ini": "b = 2",
+		})
+		t.Setenv("HOME", filepath.Join(dir, "home"))
+		err := os.WriteFile(filepath.Join(dir, ".myapprc.ini"), []byte("c = 3"), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ini := NewINIWalk(filepath.Join(dir, "home", "sub"), ".myapprc.ini")
+		a := ini.Int("a", -1, "")
+		b := ini.Int("b", -1, "")
+
+		err = ini.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *a != 1 || *b != 2 {
+			t.Fatal("unexpected value", *a, *b)
+		}
+	})
+}
+
+func TestSystemdCredentials(t *testing.T) {
+	setup := func(t *testing.T, creds map[string]string) (*Figue, *string, *int) {
+		t.Setenv("CREDENTIALS_DIRECTORY", writeFiles(t, creds))
+
+		f := New("myapp", ContinueOnError, NewSystemdCredentials())
+		f.SetOutput(io.Discard)
+		password := f.String("db.password", "", "database password")
+		port := f.Int("db.port", 5432, "database port")
+		return f, password, port
+	}
+
+	t.Run("Unset", func(t *testing.T) {
+		f, password, port := setup(t, nil)
+		t.Setenv("CREDENTIALS_DIRECTORY", "")
+
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *password != "" || *port != 5432 {
+			t.Fatal("unexpected values", *password, *port)
+		}
+	})
+
+	t.Run("Load", func(t *testing.T) {
+		f, password, port := setup(t, map[string]string{
+			"db.password": "p4$${HOME}\n",
+			"db_port":     "5433",
+			"unknown":     "ignored",
+		})
+
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *password != "p4$${HOME}" || !f.IsSecret("db.password") {
+			t.Fatal("unexpected password", *password)
+		}
+		if *port != 5433 {
+			t.Fatal("unexpected port", *port)
+		}
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		f, _, _ := setup(t, map[string]string{
+			"db.port": "5433",
+			"db_port": "5434",
+		})
+
+		err := f.Parse()
+		if err == nil || err.Error() != "systemd credentials db.port and db_port are both set" {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		f, _, _ := setup(t, map[string]string{"db.port": "not a port"})
+
+		err := f.Parse()
+		if err == nil || !strings.HasPrefix(err.Error(), "invalid value for systemd credential db.port") {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("PrintDefaults", func(t *testing.T) {
+		f, _, _ := setup(t, nil)
+		t.Setenv("CREDENTIALS_DIRECTORY", "/run/credentials/myapp.service")
+
+		var output strings.Builder
+		f.SetOutput(&output)
+		f.PrintDefaults()
+
+		expected := "Systemd credentials of myapp ($CREDENTIALS_DIRECTORY is /run/credentials/myapp.service):\n" +
+			"  db.password, db_password\n    \tdatabase password\n" +
+			"  db.port, db_port\n    \tdatabase port\n"
+		if output.String() != expected {
+			t.Fatal("unexpected output", output.String())
+		}
+	})
+}
+
+func TestDir(t *testing.T) {
+	// setup creates a directory with the atomic symbolic link layout of
+	// Kubernetes volumes.
+	setup := func(t *testing.T, dir, version string, files map[string]string) {
+		err := os.MkdirAll(filepath.Join(dir, version), 0o700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range files {
+			err := os.WriteFile(filepath.Join(dir, version, name), []byte(content), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name))
+		}
+
+		tmp := filepath.Join(dir, "..data_tmp")
+		err = os.Symlink(version, tmp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.Rename(tmp, filepath.Join(dir, "..data"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Load", func(t *testing.T) {
+		dir := t.TempDir()
+		setup(t, dir, "..2024_01_01", map[string]string{
+			"db.host":       "localhost\n",
+			"db__port":      "5433",
+			"unknown":       "ignored",
+			"http.timeout":  "1s",
+			"..db.user":     "ignored",
+			"..data_backup": "ignored",
+		})
+
+		backend := NewDir(dir)
+		f := New("myapp", ContinueOnError, backend)
+		f.SetOutput(io.Discard)
+		host := f.String("db.host", "", "database host")
+		port := f.Int("db.port", 5432, "database port")
+		user := f.String("db.user", "postgres", "database user")
+		// Separator applies to options defined before it is set.
+		backend.Separator = "__"
+
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *host != "localhost" || *port != 5433 || *user != "postgres" {
+			t.Fatal("unexpected values", *host, *port, *user)
+		}
+		if f.IsSecret("db.host") {
+			t.Fatal("option file value reported as secret")
+		}
+
+		var output strings.Builder
+		f.SetOutput(&output)
+		f.PrintDefaults()
+		expected := "Option files of myapp are read from directory " + dir + ":\n" +
+			"  db.host, db__host\n    \tdatabase host\n" +
+			"  db.port, db__port\n    \tdatabase port\n" +
+			"  db.user, db__user\n    \tdatabase user\n"
+		if output.String() != expected {
+			t.Fatal("unexpected output", output.String())
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		dir := t.TempDir()
+		setup(t, dir, "..2024_01_01", map[string]string{"port": "not a port"})
+
+		f := New("myapp", ContinueOnError, NewDir(dir))
+		f.SetOutput(io.Discard)
+		_ = f.Int("port", 0, "")
+
+		err := f.Parse()
+		if err == nil || !strings.HasPrefix(err.Error(), `invalid value "not a port" for option file port`) {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("Secret", func(t *testing.T) {
+		dir := t.TempDir()
+		setup(t, dir, "..2024_01_01", map[string]string{"db.password": "p4$${x}\n", "port": "s3cr3t"})
+
+		backend := NewDir(dir)
+		backend.Secret = true
+		f := New("myapp", ContinueOnError, backend)
+		f.SetOutput(io.Discard)
+		password := f.String("db.password", "", "database password")
+
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *password != "p4$${x}" || !f.IsSecret("db.password") {
+			t.Fatal("unexpected password", *password)
+		}
+
+		_ = f.Int("port", 0, "")
+		err = f.Parse()
+		if err == nil || strings.Contains(err.Error(), "s3cr3t") {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		dir := t.TempDir()
+		setup(t, dir, "..2024_01_01", map[string]string{"port": "8080"})
+
+		backend := NewDir(dir)
+		f := New("myapp", ContinueOnError, backend)
+		f.SetOutput(io.Discard)
+		port := f.Int("port", 0, "")
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		changed := make(chan error)
+		go func() {
+			_ = backend.Watch(ctx, time.Millisecond, func() {
+				changed <- f.Parse()
+			})
+		}()
+		defer cancel()
+
+		setup(t, dir, "..2024_01_02", map[string]string{"port": "8081"})
+		select {
+		case err := <-changed:
+			if err != nil {
+				t.Fatal("unexpected parse error", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("swap not detected")
+		}
+		if *port != 8081 {
+			t.Fatal("unexpected port", *port)
+		}
+	})
+}
+
+func TestVerifySignatures(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(t *testing.T, fpath, content string, signed bool) {
+		err := os.WriteFile(fpath, []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if signed {
+			sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(content)))
+			err = os.WriteFile(fpath+SignatureSuffix, []byte(sig+"\n"), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	t.Run("Ini", func(t *testing.T) {
+		for _, test := range []struct {
+			name     string
+			setup    func(t *testing.T, fpath string)
+			keys     []ed25519.PublicKey
+			expected error
+		}{
+			{
+				name: "Valid",
+				setup: func(t *testing.T, fpath string) {
+					write(t, fpath, "include = other.ini\nport = 8080\n", true)
+					write(t, filepath.Join(filepath.Dir(fpath), "other.ini"), "host = example.com\n", true)
+				},
+				keys: []ed25519.PublicKey{otherPub, pub},
+			},
+			{
+				name: "Unsigned",
+				setup: func(t *testing.T, fpath string) {
+					write(t, fpath, "port = 8080\n", false)
+				},
+				keys:     []ed25519.PublicKey{pub},
+				expected: ErrUnsignedFile,
+			},
+			{
+				name: "UnsignedInclude",
+				setup: func(t *testing.T, fpath string) {
+					write(t, fpath, "include = other.ini\nport = 8080\n", true)
+					write(t, filepath.Join(filepath.Dir(fpath), "other.ini"), "host = example.com\n", false)
+				},
+				keys:     []ed25519.PublicKey{pub},
+				expected: ErrUnsignedFile,
+			},
+			{
+				name: "Tampered",
+				setup: func(t *testing.T, fpath string) {
+					write(t, fpath, "port = 8080\n", true)
+					err := os.WriteFile(fpath, []byte("port = 8081\n"), 0o600)
+					if err != nil {
+						t.Fatal(err)
+					}
+				},
+				keys:     []ed25519.PublicKey{pub},
+				expected: ErrInvalidSignature,
+			},
+			{
+				name: "UntrustedKey",
+				setup: func(t *testing.T, fpath string) {
+					write(t, fpath, "port = 8080\n", true)
+				},
+				keys:     []ed25519.PublicKey{otherPub},
+				expected: ErrInvalidSignature,
+			},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				fpath := filepath.Join(t.TempDir(), "config.ini")
+				test.setup(t, fpath)
+
+				f := New("myapp", ContinueOnError, NewINI(fpath))
+				f.SetOutput(io.Discard)
+				port := f.Int("port", 0, "")
+				host := f.String("host", "", "")
+				f.VerifySignatures(test.keys...)
+
+				err := f.Parse()
+				if !errors.Is(err, test.expected) {
+					t.Fatal("unexpected parse error", err)
+				}
+				var sigErr *SignatureError
+				if test.expected != nil && !errors.As(err, &sigErr) {
+					t.Fatal("signature error expected", err)
+				}
+				if test.expected == nil && (*port != 8080 || *host != "example.com") {
+					t.Fatal("unexpected values", *port, *host)
+				}
+				if test.expected != nil && *port != 0 {
+					t.Fatal("values of refused file applied", *port)
+				}
+			})
+		}
+	})
+
+	t.Run("Unnamed", func(t *testing.T) {
+		ini := NewINI("")
+		ini.Open = func() (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("port = 8080\n")), nil
+		}
+		f := New("myapp", ContinueOnError, ini)
+		f.SetOutput(io.Discard)
+		port := f.Int("port", 0, "")
+		f.VerifySignatures(pub)
+
+		err := f.Parse()
+		if !errors.Is(err, ErrUnnamedFile) || *port != 0 {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("Dir", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, filepath.Join(dir, "host"), "example.com\n", true)
+		write(t, filepath.Join(dir, "port"), "8080\n", false)
+
+		f := New("myapp", ContinueOnError, NewDir(dir))
+		f.SetOutput(io.Discard)
+		host := f.String("host", "", "")
+		_ = f.Int("port", 0, "")
+		f.VerifySignatures(pub)
+
+		err := f.Parse()
+		if !errors.Is(err, ErrUnsignedFile) || err.Error() != "signature verification of "+filepath.Join(dir, "port")+" failed: file isn't signed" {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *host != "" {
+			t.Fatal("values applied before verification of all files", *host)
+		}
+	})
+}
+
+func TestFilePolicy(t *testing.T) {
+	policy := &FilePolicy{
+		RejectWorldWritable: true,
+		RejectForeignOwner:  true,
+		RejectSymlinks:      true,
+		MaxSize:             1024,
+		SecretOptions:       []string{"db.password"},
+	}
+
+	parse := func(t *testing.T, fpath string) (string, error) {
+		var output strings.Builder
+		ini := NewINI(fpath)
+		ini.Policy = policy
+		ini.SetOutput(&output)
+		_ = ini.String("db.password", "", "")
+		_ = ini.String("db.user", "", "")
+		err := ini.Parse()
+		return output.String(), err
+	}
+	write := func(t *testing.T, content string, perm os.FileMode) string {
+		fpath := filepath.Join(t.TempDir(), "config.ini")
+		err := os.WriteFile(fpath, []byte(content), perm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.Chmod(fpath, perm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fpath
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		fpath := write(t, "[db]\npassword = p4ssw0rd\n", 0o600)
+		output, err := parse(t, fpath)
+		if err != nil || output != "" {
+			t.Fatal("unexpected parse error", err, output)
+		}
+	})
+
+	t.Run("ReadableSecret", func(t *testing.T) {
+		fpath := write(t, "[db]\npassword = p4ssw0rd\n", 0o640)
+		output, err := parse(t, fpath)
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if output != "warning: "+fpath+" sets secret option db.password but is readable by group or others (mode -rw-r-----)\n" {
+			t.Fatal("unexpected output", output)
+		}
+
+		// No secret option set.
+		fpath = write(t, "[db]\nuser = postgres\n", 0o644)
+		output, err = parse(t, fpath)
+		if err != nil || output != "" {
+			t.Fatal("unexpected parse error", err, output)
+		}
+
+		// Files of FS aren't checked against host files of the same path.
+		fpath = write(t, "", 0o644)
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.Chdir(filepath.Dir(fpath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = os.Chdir(wd) }()
+		fsys := fstest.MapFS{"config.ini": {Data: []byte("[db]\npassword = p4ssw0rd\n"), Mode: 0o644}}
+		var b strings.Builder
+		ini := NewINIFS(fsys, "config.ini")
+		ini.Policy = policy
+		ini.SetOutput(&b)
+		_ = ini.String("db.password", "", "")
+		err = ini.Parse()
+		if err != nil || b.String() != "" {
+			t.Fatal("unexpected parse error", err, b.String())
+		}
+	})
+
+	t.Run("WorldWritable", func(t *testing.T) {
+		fpath := write(t, "[db]\nuser = postgres\n", 0o666)
+		_, err := parse(t, fpath)
+		var policyErr *FilePolicyError
+		if !errors.Is(err, ErrWorldWritable) || !errors.As(err, &policyErr) || policyErr.File != fpath {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("WorldWritableInclude", func(t *testing.T) {
+		included := write(t, "[db]\nuser = postgres\n", 0o666)
+		fpath := write(t, "include = "+included+"\n", 0o600)
+		_, err := parse(t, fpath)
+		if !errors.Is(err, ErrWorldWritable) {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("Symlink", func(t *testing.T) {
+		target := write(t, "[db]\nuser = postgres\n", 0o600)
+		fpath := filepath.Join(t.TempDir(), "config.ini")
+		err := os.Symlink(target, fpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = parse(t, fpath)
+		if !errors.Is(err, ErrSymlink) {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("SecretFile", func(t *testing.T) {
+		secret := write(t, "p4ssw0rd\n", 0o666)
+		link := filepath.Join(t.TempDir(), "password")
+		err := os.Symlink(write(t, "p4ssw0rd\n", 0o600), link)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for doc, expected := range map[string]error{
+			"[db]\npassword_file = " + secret + "\n": ErrWorldWritable,
+			"[db]\npassword = @file:" + link + "\n":  ErrSymlink,
+		} {
+			ini := NewINI(write(t, doc, 0o600))
+			ini.Policy = policy
+			ini.SetOutput(io.Discard)
+			_ = ini.String("db.password", "", "")
+			ini.SecretFiles("db.password")
+			err := ini.Parse()
+			if !errors.Is(err, expected) {
+				t.Fatalf("unexpected parse error for %q: %v", doc, err)
+			}
+		}
+	})
+
+	t.Run("WalkRoot", func(t *testing.T) {
+		fpath := write(t, "root = true\n", 0o666)
+		walk := NewINIWalk(filepath.Dir(fpath), "config.ini")
+		walk.Policy = policy
+		_, err := walk.Files()
+		if !errors.Is(err, ErrWorldWritable) {
+			t.Fatal("unexpected files error", err)
+		}
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		fpath := write(t, "[db]\nuser = "+strings.Repeat("a", 1024)+"\n", 0o600)
+		_, err := parse(t, fpath)
+		if !errors.Is(err, ErrFileTooLarge) {
+			t.Fatal("unexpected parse error", err)
+		}
+
+		// Size of devices isn't known before reading.
+		if _, err := os.Stat("/dev/zero"); err == nil {
+			ini := NewINI("/dev/zero")
+			ini.Policy = &FilePolicy{MaxSize: 1024}
+			err = ini.Parse()
+			if !errors.Is(err, ErrFileTooLarge) {
+				t.Fatal("unexpected parse error", err)
+			}
+		}
+	})
+
+	t.Run("ForeignOwner", func(t *testing.T) {
+		if os.Getuid() != 0 {
+			t.Skip("changing file owner requires root")
+		}
+		fpath := write(t, "[db]\nuser = postgres\n", 0o600)
+		err := os.Chown(fpath, 65534, 65534)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = parse(t, fpath)
+		if !errors.Is(err, ErrForeignOwner) {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+}
+
+func TestHTTP(t *testing.T) {
+	const etag = `"v1"`
+	var (
+		doc      = `{"db": {"host": "example.com"}, "db.port": 5433, "tags": ["a", "b"]}`
+		status   = http.StatusOK
+		requests []*http.Request
+	)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = io.WriteString(w, doc)
+	})
+
+	setup := func(t *testing.T, url string, opts HTTPOptions) (*Figue, *HTTP, *string, *int) {
+		requests = nil
+		status = http.StatusOK
+		backend := NewHTTP(url, opts)
+		f := New("myapp", ContinueOnError, backend)
+		f.SetOutput(io.Discard)
+		host := f.String("db.host", "localhost", "database host")
+		port := f.Int("db.port", 5432, "database port")
+		_ = f.String("tags", "", "tags")
+		return f, backend, host, port
+	}
+
+	t.Run("ETag", func(t *testing.T) {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		f, backend, host, port := setup(t, server.URL, HTTPOptions{
+			Header: http.Header{"Authorization": {"Bearer token"}},
+		})
+
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *host != "example.com" || *port != 5433 || !backend.Changed() {
+			t.Fatal("unexpected values", *host, *port)
+		}
+		if requests[0].Header.Get("Authorization") != "Bearer token" {
+			t.Fatal("header not sent", requests[0].Header)
+		}
+
+		// Unchanged document is served from cache.
+		*host, *port = "", 0
+		err = f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if requests[1].Header.Get("If-None-Match") != etag || backend.Changed() {
+			t.Fatal("conditional request expected", requests[1].Header)
+		}
+		if *host != "example.com" || *port != 5433 {
+			t.Fatal("cached values not applied", *host, *port)
+		}
+	})
+
+	t.Run("FailurePolicy", func(t *testing.T) {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		f, _, _, _ := setup(t, server.URL, HTTPOptions{})
+		status = http.StatusInternalServerError
+		err := f.Parse()
+		if err == nil || err.Error() != "failed to fetch remote options from "+server.URL+": unexpected status 500 Internal Server Error" {
+			t.Fatal("unexpected parse error", err)
+		}
+
+		f, backend, host, _ := setup(t, server.URL, HTTPOptions{FailurePolicy: UseCachedOnHTTPFailure})
+		err = f.Parse()
+		if err != nil || *host != "example.com" {
+			t.Fatal("unexpected parse error", err, *host)
+		}
+		status = http.StatusServiceUnavailable
+		*host = ""
+		err = f.Parse()
+		if err != nil || backend.LastError() == nil {
+			t.Fatal("unexpected parse error", err, backend.LastError())
+		}
+		if *host != "example.com" {
+			t.Fatal("cached values not applied", *host)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		f, _, _, _ := setup(t, server.URL, HTTPOptions{Timeout: 10 * time.Millisecond})
+		err := f.Parse()
+		if err == nil || !strings.Contains(err.Error(), "Client.Timeout exceeded") {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		f, _, _, _ := setup(t, server.URL, HTTPOptions{Context: ctx})
+		err := f.Parse()
+		if !errors.Is(err, context.Canceled) {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("DefaultTransport", func(t *testing.T) {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+
+		defaultTransport := http.DefaultTransport
+		http.DefaultTransport = roundTripperFunc(defaultTransport.RoundTrip)
+		t.Cleanup(func() { http.DefaultTransport = defaultTransport })
+
+		f, _, host, _ := setup(t, server.URL, HTTPOptions{})
+		err := f.Parse()
+		if err != nil || *host != "example.com" {
+			t.Fatal("unexpected parse error", err, *host)
+		}
+	})
+
+	t.Run("TLS", func(t *testing.T) {
+		server := httptest.NewTLSServer(handler)
+		defer server.Close()
+
+		f, _, _, _ := setup(t, server.URL, HTTPOptions{})
+		err := f.Parse()
+		if err == nil || !strings.Contains(err.Error(), "certificate") {
+			t.Fatal("unexpected parse error", err)
+		}
+
+		roots := x509.NewCertPool()
+		roots.AddCert(server.Certificate())
+		f, _, host, _ := setup(t, server.URL, HTTPOptions{TLSConfig: &tls.Config{RootCAs: roots}})
+		err = f.Parse()
+		if err != nil || *host != "example.com" {
+			t.Fatal("unexpected parse error", err, *host)
+		}
+	})
+
+	t.Run("Undefined", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `{"db": {"host": "example.com", "user": "postgres"}}`)
+		}))
+		defer server.Close()
+
+		f, _, _, _ := setup(t, server.URL, HTTPOptions{})
+		err := f.Parse()
+		if err == nil || err.Error() != "remote option provided but not defined: db.user" {
+			t.Fatal("unexpected parse error", err)
+		}
+
+		f, _, host, _ := setup(t, server.URL, HTTPOptions{IgnoreUndefined: true})
+		err = f.Parse()
+		if err != nil || *host != "example.com" {
+			t.Fatal("unexpected parse error", err, *host)
+		}
+	})
+
+	t.Run("PrintDefaults", func(t *testing.T) {
+		f, _, _, _ := setup(t, "https://config.example.com/myapp", HTTPOptions{})
+		var output strings.Builder
+		f.SetOutput(&output)
+		f.PrintDefaults()
+
+		expected := "Remote options of myapp are fetched from https://config.example.com/myapp:\n" +
+			"  db.host\n    \tdatabase host\n" +
+			"  db.port\n    \tdatabase port\n" +
+			"  tags\n    \ttags\n"
+		if output.String() != expected {
+			t.Fatal("unexpected output", output.String())
+		}
+	})
+}
+
+// roundTripperFunc is an http.RoundTripper calling itself.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
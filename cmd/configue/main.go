@@ -0,0 +1,184 @@
+// Command configue provides helpers to manage configue configuration files.
+//
+// Usage:
+//
+//	configue keygen [-ed25519 path]
+//	configue encrypt [-key-file path | -key-env name] [value]
+//	configue sign -key-file path file...
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/negrel/configue"
+	"github.com/negrel/configue/option"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "keygen":
+		err = keygen(os.Args[2:])
+	case "encrypt":
+		err = encrypt(os.Args[2:])
+	case "sign":
+		err = sign(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		usage()
+		return
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "configue:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage: configue <command> [arguments]
+
+Commands:
+  keygen   generate a base64 encoded AES-256 key or ed25519 key pair
+  encrypt  encrypt a value as ENC[AES256_GCM,...]
+  sign     write detached ed25519 signatures of configuration files`)
+}
+
+// keygen prints a new base64 encoded AES-256 key or writes a new ed25519 key
+// pair.
+func keygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	ed25519Path := fs.String("ed25519", "", "write base64 encoded ed25519 private key to `path` and public key to path.pub instead")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if *ed25519Path != "" {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(*ed25519Path, []byte(base64.StdEncoding.EncodeToString(priv.Seed())+"\n"), 0o600)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(*ed25519Path+".pub", []byte(base64.StdEncoding.EncodeToString(pub)+"\n"), 0o644)
+	}
+
+	key := make([]byte, 32)
+	_, err = rand.Read(key)
+	if err != nil {
+		return err
+	}
+	fmt.Println(base64.StdEncoding.EncodeToString(key))
+	return nil
+}
+
+// encrypt prints encrypted value provided as argument or on standard input.
+func encrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	keyFile := fs.String("key-file", "", "path of file containing base64 encoded key")
+	keyEnv := fs.String("key-env", "CONFIGUE_KEY", "env var containing base64 encoded key, if -key-file isn't set")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: configue encrypt [-key-file path | -key-env name] [value]")
+		fmt.Fprintln(fs.Output(), "\nValue is read from standard input if not provided as argument.")
+		fs.PrintDefaults()
+	}
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	keyFunc := configue.KeyEnv(*keyEnv)
+	if *keyFile != "" {
+		keyFunc = configue.KeyFile(*keyFile)
+	}
+	key, err := keyFunc()
+	if err != nil {
+		return err
+	}
+
+	var value string
+	switch fs.NArg() {
+	case 0:
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimSuffix(string(content), "\n")
+	case 1:
+		value = fs.Arg(0)
+	default:
+		fs.Usage()
+		return errors.New("too many arguments")
+	}
+
+	encrypted, err := option.EncryptAESGCM(key, value)
+	if err != nil {
+		return err
+	}
+	fmt.Println(encrypted)
+	return nil
+}
+
+// sign writes detached signatures of files.
+func sign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ContinueOnError)
+	keyFile := fs.String("key-file", "", "path of file containing base64 encoded ed25519 private key")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: configue sign -key-file path file...")
+		fmt.Fprintf(fs.Output(), "\nSignature of each file is written to file%v.\n", configue.SignatureSuffix)
+		fs.PrintDefaults()
+	}
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+	if *keyFile == "" || fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("missing private key or files")
+	}
+
+	content, err := os.ReadFile(*keyFile)
+	if err != nil {
+		return err
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return errors.New("invalid ed25519 private key")
+	}
+	priv := ed25519.NewKeyFromSeed(seed)
+
+	for _, fpath := range fs.Args() {
+		content, err := os.ReadFile(fpath)
+		if err != nil {
+			return err
+		}
+		sig := ed25519.Sign(priv, content)
+		err = os.WriteFile(fpath+configue.SignatureSuffix, []byte(base64.StdEncoding.EncodeToString(sig)+"\n"), 0o644)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
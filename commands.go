@@ -0,0 +1,145 @@
+package configue
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultCommandTimeout is the timeout of commands run to get option values
+// if [Figue.CommandTimeout] is zero.
+const DefaultCommandTimeout = 10 * time.Second
+
+// SecretCommands enables reading value of options at paths from the output of
+// a command using "name = !cmd name args..." or "name_cmd = name args..." for
+// INI backends and PREFIX_NAME=!cmd or PREFIX_NAME_CMD env vars for [Env]
+// backends. Other backends, such as [HTTP] or [Dir], never run commands and
+// set "!cmd" values as is. Commands are
+// run at parse time without a shell and must complete within
+// [Figue.CommandTimeout]. Arguments are separated by spaces; single and double
+// quotes group arguments containing spaces and a backslash escapes the next
+// character outside single quotes. Their output, without trailing newline, is never
+// interpolated and is reported by [Figue.IsSecret]. For safety, commands are
+// only run for options listed explicitly and "!cmd" values of other options
+// are refused by INI and env backends.
+func (f *Figue) SecretCommands(paths ...string) {
+	if f.commands == nil {
+		f.commands = make(map[string]bool)
+	}
+	for _, path := range paths {
+		f.commands[path] = true
+	}
+
+	for _, b := range f.backends {
+		if b, ok := b.(interface{ SecretCommands(...string) }); ok {
+			b.SecretCommands(paths...)
+		}
+	}
+}
+
+// setCommand sets value of option at path to the output of command line
+// cmdline.
+func (f *Figue) setCommand(path, cmdline string) error {
+	if !f.commands[path] {
+		return fmt.Errorf("commands aren't enabled for option %s", path)
+	}
+
+	str, err := f.runCommand(cmdline)
+	if err != nil {
+		return err
+	}
+	return f.setSecret(path, str)
+}
+
+// runCommand runs command line cmdline and returns its standard output
+// without trailing newline.
+func (f *Figue) runCommand(cmdline string) (string, error) {
+	args, err := splitCommand(cmdline)
+	if err != nil {
+		return "", fmt.Errorf("invalid command %q: %w", cmdline, err)
+	}
+	if len(args) == 0 {
+		return "", errors.New("empty command")
+	}
+
+	timeout := f.CommandTimeout
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Don't wait for children of killed command holding output pipes.
+	cmd.WaitDelay = 100 * time.Millisecond
+
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("command %q timed out after %v", cmdline, timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("command %q failed: %v: %s", cmdline, err, msg)
+		}
+		return "", fmt.Errorf("command %q failed: %v", cmdline, err)
+	}
+
+	out := strings.TrimSuffix(stdout.String(), "\n")
+	return strings.TrimSuffix(out, "\r"), nil
+}
+
+// splitCommand splits command line cmdline into arguments as described in
+// [Figue.SecretCommands].
+func splitCommand(cmdline string) ([]string, error) {
+	var (
+		args   []string
+		arg    strings.Builder
+		inArg  bool
+		quote  rune
+		escape bool
+	)
+	for _, r := range cmdline {
+		switch {
+		case escape:
+			arg.WriteRune(r)
+			escape = false
+		case r == '\\' && quote != '\'':
+			escape, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escape {
+		return nil, errors.New("trailing backslash")
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed %c quote", quote)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,216 @@
+package configue
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/negrel/configue/ini"
+	"github.com/negrel/configue/option"
+)
+
+// Format defines a configuration document format.
+type Format int
+
+const (
+	// FormatINI is the INI format as parsed by the ini package.
+	FormatINI Format = iota
+	// FormatJSON is the JSON format. Nested objects are flattened into option
+	// paths ({"db": {"port": 5432}} sets db.port) and arrays into
+	// comma-separated values.
+	FormatJSON
+)
+
+// String implements fmt.Stringer.
+func (f Format) String() string {
+	switch f {
+	case FormatINI:
+		return "INI"
+	case FormatJSON:
+		return "JSON"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// Defaults reads document r in the given format and uses its values as
+// default values of options, overriding values provided when defining them.
+// This is the lowest priority layer of configuration: backends override it.
+// Defaults may be called before or after defining options but must be called
+// before Parse. Invalid default values of options defined afterward are
+// reported by Parse. Default values are reflected by PrintDefaults. INI
+// documents can't include other files.
+func (f *Figue) Defaults(r io.Reader, format Format) error {
+	var (
+		kvs []ini.KeyValue
+		err error
+	)
+	switch format {
+	case FormatINI:
+		kvs, err = ini.ReadAll(r)
+		for _, kv := range kvs {
+			// Included files can't be located relative to a reader.
+			if kv.Key == "include" || kv.Key == "include?" {
+				err = fmt.Errorf("%v: %v directives aren't supported in default values", kv.Pos, kv.Key)
+				break
+			}
+		}
+	case FormatJSON:
+		kvs, err = readJSON(r)
+	default:
+		err = fmt.Errorf("unsupported format %v", format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read default values: %w", err)
+	}
+
+	if f.defaults == nil {
+		f.defaults = make(map[string]string)
+	}
+	for _, kv := range kvs {
+		if kv.Profile != "" {
+			return fmt.Errorf("profile sections aren't supported in default values: %s", kv.Profile)
+		}
+
+		f.defaults[kv.Key] = kv.Value
+
+		val, ok := f.vars[kv.Key]
+		if !ok {
+			// Default is applied when option is defined.
+			continue
+		}
+		err := setDefault(val, kv.Value)
+		if err != nil {
+			return fmt.Errorf("invalid default value %q for option %s: %w", kv.Value, kv.Key, err)
+		}
+		for _, b := range f.backends {
+			setDefValue(b, kv.Key, val.String())
+		}
+	}
+
+	return nil
+}
+
+// setDefault sets default value of val.
+func setDefault(val option.Value, def string) error {
+	if d, ok := val.(interface{ SetDefault(string) error }); ok {
+		return d.SetDefault(def)
+	}
+	return val.Set(def)
+}
+
+// checkDefaults returns an error if a default value was provided for an
+// undefined option or is invalid.
+func (f *Figue) checkDefaults() error {
+	if f.defaultErr != nil {
+		return f.defaultErr
+	}
+	for path := range f.defaults {
+		if _, ok := f.vars[path]; !ok {
+			return fmt.Errorf("default value provided but not defined: %s", path)
+		}
+	}
+	return nil
+}
+
+// defValueSetter is implemented by backends whose option names differ from
+// option paths.
+type defValueSetter interface {
+	setDefValue(path, defValue string)
+}
+
+// setDefValue updates default value of option at path in backend b.
+func setDefValue(b Backend, path, defValue string) {
+	switch b := b.(type) {
+	case defValueSetter:
+		b.setDefValue(path, defValue)
+	case interface{ Lookup(string) *option.Option }:
+		if opt := b.Lookup(path); opt != nil {
+			opt.DefValue = defValue
+		}
+	}
+}
+
+// readJSON reads a JSON object from r and flattens it into key value
+// pairs sorted by key.
+func readJSON(r io.Reader) ([]ini.KeyValue, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var obj map[string]any
+	err := dec.Decode(&obj)
+	if err != nil {
+		return nil, err
+	}
+
+	var kvs []ini.KeyValue
+	err = flattenJSON(obj, "", &kvs)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(kvs, func(a, b ini.KeyValue) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return kvs, nil
+}
+
+func flattenJSON(obj map[string]any, prefix string, kvs *[]ini.KeyValue) error {
+	for k, v := range obj {
+		key := prefix + k
+
+		switch v := v.(type) {
+		case nil:
+			continue
+
+		case map[string]any:
+			err := flattenJSON(v, key+".", kvs)
+			if err != nil {
+				return err
+			}
+
+		case []any:
+			record := make([]string, len(v))
+			for i, elem := range v {
+				str, ok := jsonScalar(elem)
+				if !ok {
+					return fmt.Errorf("unsupported array element for %s: %v", key, elem)
+				}
+				record[i] = str
+			}
+
+			var b strings.Builder
+			w := csv.NewWriter(&b)
+			_ = w.Write(record)
+			w.Flush()
+			*kvs = append(*kvs, ini.KeyValue{Key: key, Value: strings.TrimSuffix(b.String(), "\n")})
+
+		default:
+			str, ok := jsonScalar(v)
+			if !ok {
+				return fmt.Errorf("unsupported value for %s: %v", key, v)
+			}
+			*kvs = append(*kvs, ini.KeyValue{Key: key, Value: str})
+		}
+	}
+
+	return nil
+}
+
+// jsonScalar returns string representation of a JSON string, number or
+// boolean.
+func jsonScalar(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return fmt.Sprint(v), true
+	default:
+		return "", false
+	}
+}
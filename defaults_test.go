@@ -0,0 +1,147 @@
+package configue
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDefaults(t *testing.T) {
+	newFigue := func(t *testing.T, ini string) (*Figue, *strings.Builder) {
+		fpath := filepath.Join(t.TempDir(), "config.ini")
+		if ini != "" {
+			err := os.WriteFile(fpath, []byte(ini), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		var output strings.Builder
+		f := New("myapp", ContinueOnError, NewINI(fpath), NewEnv("MYAPP"))
+		f.SetOutput(&output)
+		return f, &output
+	}
+
+	t.Run("INI/BeforeVar", func(t *testing.T) {
+		f, output := newFigue(t, "")
+		err := f.Defaults(strings.NewReader("[db]\nport = 5433\n"), FormatINI)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		port := f.Int("db.port", 5432, "database port")
+		if *port != 5433 {
+			t.Fatal("default value not applied", *port)
+		}
+
+		err = f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *port != 5433 {
+			t.Fatal("default value overwritten", *port)
+		}
+
+		f.PrintDefaults()
+		if !strings.Contains(output.String(), "(default 5433)") {
+			t.Fatal("default value not printed:", output.String())
+		}
+		if prop := f.backends[0].(*Ini).Lookup("db.port"); prop.DefValue != "5433" {
+			t.Fatal("INI default value not updated", prop.DefValue)
+		}
+	})
+
+	t.Run("JSON/AfterVar", func(t *testing.T) {
+		f, output := newFigue(t, "")
+		port := f.Int("db.port", 5432, "database port")
+		hosts := f.StringSlice("db.hosts", nil, "database hosts")
+		verbose := f.Bool("verbose", false, "verbose output")
+
+		err := f.Defaults(strings.NewReader(
+			`{"db": {"port": 5433, "hosts": ["a", "b,c"]}, "verbose": true, "unset": null}`,
+		), FormatJSON)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if *port != 5433 || !*verbose || !slices.Equal(*hosts, []string{"a", "b,c"}) {
+			t.Fatal("default values not applied", *port, *verbose, *hosts)
+		}
+
+		f.PrintDefaults()
+		if !strings.Contains(output.String(), "(default 5433)") {
+			t.Fatal("default value not printed:", output.String())
+		}
+		if prop := f.backends[0].(*Ini).Lookup("db.port"); prop.DefValue != "5433" {
+			t.Fatal("INI default value not updated", prop.DefValue)
+		}
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		f, _ := newFigue(t, "[db]\nhosts = d\n")
+		t.Setenv("MYAPP_DB_PORT", "5434")
+
+		err := f.Defaults(strings.NewReader("[db]\nport = 5433\nhosts = a,b\n"), FormatINI)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		port := f.Int("db.port", 5432, "database port")
+		hosts := f.StringSlice("db.hosts", nil, "database hosts")
+
+		err = f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *port != 5434 {
+			t.Fatal("env var doesn't override default value", *port)
+		}
+		if !slices.Equal(*hosts, []string{"d"}) {
+			t.Fatal("INI file doesn't replace default value", *hosts)
+		}
+	})
+
+	t.Run("Undefined", func(t *testing.T) {
+		f, _ := newFigue(t, "")
+		err := f.Defaults(strings.NewReader("[db]\nhost = localhost\n"), FormatINI)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		_ = f.Int("db.port", 5432, "database port")
+
+		err = f.Parse()
+		if err == nil || err.Error() != "default value provided but not defined: db.host" {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("Include", func(t *testing.T) {
+		for _, doc := range []string{"include = base.ini\n", "[db]\nport = 5433\n[]\ninclude? = local.ini\n"} {
+			f, _ := newFigue(t, "")
+			_ = f.Int("db.port", 5432, "database port")
+			err := f.Defaults(strings.NewReader(doc), FormatINI)
+			if err == nil || !strings.HasSuffix(err.Error(), "directives aren't supported in default values") {
+				t.Fatalf("unexpected error for %q: %v", doc, err)
+			}
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		f, _ := newFigue(t, "")
+		_ = f.Int("db.port", 5432, "database port")
+		err := f.Defaults(strings.NewReader(`{"db": {"port": "abc"}}`), FormatJSON)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+
+		// Invalid default of an option defined afterward is reported by Parse.
+		f, _ = newFigue(t, "")
+		err = f.Defaults(strings.NewReader(`{"db": {"port": "abc"}}`), FormatJSON)
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		_ = f.Int("db.port", 5432, "database port")
+		err = f.Parse()
+		if err == nil || !strings.HasPrefix(err.Error(), `invalid default value "abc" for option db.port: `) {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+}
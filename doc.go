@@ -41,7 +41,71 @@ they are all pointers; if you bind to variables, they're values.
 
 Options are loaded/parsed by [Backend]. Built-in flag, environment variable and
 INI file based backends are provided by [NewFlag], [NewEnv], [NewINI]
-respectively. They parse options value the same way. See
+respectively. Secrets can be loaded from systemd credentials using
+[NewSystemdCredentials] and mounted Kubernetes ConfigMaps and Secrets using
+[NewDir]. Remote options are fetched from a JSON document served over HTTP
+using [NewHTTP]. They parse options value the same way. See
 [`option`](./option#pkg-overview) documentation for more information.
+
+# Interpolation
+
+Values provided by backends may reference other options and environment
+variables. References are resolved once all backends are parsed, so they
+reflect final values:
+
+	[paths]
+	data_dir = ${HOME}/.myapp
+	cache_dir = ${paths.data_dir}/cache
+
+	[http]
+	port = ${env:PORT:-8080}
+
+"$$" escapes "$". Options referencing a secret option are secret too (see
+[Figue.IsSecret]). Interpolation can be disabled per option using
+[Figue.DisableInterpolation].
+
+# Secrets
+
+Secrets can be read from files (see [Figue.SecretFiles]) or from the output of
+commands run at parse time for options enabled with [Figue.SecretCommands]:
+
+	[db]
+	password = !cmd pass show db/prod
+	; or
+	password_cmd = pass show db/prod
+
+Values can also be committed encrypted and decrypted at parse time using
+[Figue.Decrypt]. The configue command encrypts them:
+
+	$ configue keygen > key
+	$ configue encrypt -key-file key p4ssw0rd
+	ENC[AES256_GCM,data:...,iv:...]
+
+Secret values are never interpolated and are reported by [Figue.IsSecret].
+
+# Signed configuration files
+
+[Figue.VerifySignatures] refuses configuration files, including included ones,
+without a detached ed25519 signature valid for one of trusted public keys. The
+configue command generates keys and signatures:
+
+	$ configue keygen -ed25519 signing.key
+	$ configue sign -key-file signing.key config.ini
+
+# File policies
+
+A [FilePolicy] set on INI backends refuses world-writable, foreign, symbolic
+links or too large configuration files, included files and secret files
+before they're read. It also warns about secrets set by files readable by
+other users:
+
+	ini := configue.NewINI("/etc/myapp/config.ini")
+	ini.Policy = &configue.FilePolicy{
+		RejectWorldWritable: true,
+		RejectForeignOwner:  true,
+		RejectSymlinks:      true,
+		MaxSize:             1 << 20,
+		SecretOptions:       []string{"db.password"},
+	}
 */
 package configue
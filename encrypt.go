@@ -0,0 +1,77 @@
+package configue
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/negrel/configue/option"
+)
+
+// KeyFunc returns the key used to decrypt encrypted values.
+type KeyFunc func() ([]byte, error)
+
+// KeyFile returns a KeyFunc reading a base64 encoded key from file at path.
+func KeyFile(path string) KeyFunc {
+	return func() ([]byte, error) {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		return decodeKey(string(content))
+	}
+}
+
+// KeyEnv returns a KeyFunc reading a base64 encoded key from environment
+// variable name.
+func KeyEnv(name string) KeyFunc {
+	return func() ([]byte, error) {
+		b64, ok := os.LookupEnv(name)
+		if !ok {
+			return nil, fmt.Errorf("key env var %s isn't set", name)
+		}
+		return decodeKey(b64)
+	}
+}
+
+// decodeKey decodes base64 encoded key.
+func decodeKey(b64 string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b64))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 key: %w", err)
+	}
+	return key, nil
+}
+
+// Decrypter decrypts "ENC[...]" values. Returned errors must not contain
+// plaintext.
+type Decrypter func(value string) (string, error)
+
+// AESGCM returns a Decrypter of values encrypted with
+// [option.EncryptAESGCM]. key is called once, when the first encrypted value
+// is decrypted.
+func AESGCM(key KeyFunc) Decrypter {
+	var (
+		k      []byte
+		keyErr error
+		loaded bool
+	)
+	return func(value string) (string, error) {
+		if !loaded {
+			k, keyErr = key()
+			loaded = true
+		}
+		if keyErr != nil {
+			return "", keyErr
+		}
+		return option.DecryptAESGCM(k, value)
+	}
+}
+
+// Decrypt enables decryption of "ENC[...]" values provided by backends using
+// dec, typically [AESGCM]. Decrypted values are never interpolated and are
+// reported by [Figue.IsSecret].
+func (f *Figue) Decrypt(dec Decrypter) {
+	f.decrypter = dec
+}
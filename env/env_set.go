@@ -40,6 +40,13 @@ type EnvSet struct {
 	output        io.Writer
 	errorHandling ErrorHandling
 	Usage         func()
+	// Env vars whose value can be read from a file and env vars that were.
+	secretFiles    map[string]bool
+	allSecretFiles bool
+	secrets        map[string]bool
+	commands       map[string]bool
+	// Names of parsed env vars.
+	parsing map[string]bool
 }
 
 // Init sets the name and error handling property for a env var set. By default,
@@ -125,6 +132,15 @@ func (es *EnvSet) Set(name, value string) error {
 func (es *EnvSet) Parse(envvars []string) error {
 	es.parsed = true
 
+	if es.allSecretFiles || len(es.secretFiles) > 0 {
+		es.parsing = make(map[string]bool, len(envvars))
+		for _, envVar := range envvars {
+			key, _, _ := strings.Cut(envVar, "=")
+			es.parsing[key] = true
+		}
+		defer func() { es.parsing = nil }()
+	}
+
 	for _, envVar := range envvars {
 		seen, err := es.parseOne(envVar)
 		if seen {
@@ -157,9 +173,23 @@ func (es *EnvSet) parseOne(envVar string) (bool, error) {
 	key := splitted[0]
 	val := splitted[1]
 
+	// Command indirection: NAME_CMD=command or NAME=!cmd command.
+	if name, ok := strings.CutSuffix(key, "_CMD"); ok && es.formal[key] == nil && es.isCommand(name) {
+		return es.parseCommand(name, val)
+	}
+	if cmdline, ok := strings.CutPrefix(val, option.CommandPrefix); ok && es.formal[key] != nil && len(es.commands) > 0 {
+		if !es.isCommand(key) {
+			return false, es.failf("commands aren't enabled for env var %s", key)
+		}
+		return es.parseCommand(key, cmdline)
+	}
+
 	// Lookup env var.
 	env, ok := es.formal[key]
 	if !ok {
+		if name, ok := strings.CutSuffix(key, "_FILE"); ok && es.isSecretFile(name) {
+			return es.parseSecretFile(name, val)
+		}
 		if IgnoreUndefined {
 			return true, nil
 		}
@@ -189,6 +219,110 @@ func (es *EnvSet) parseOne(envVar string) (bool, error) {
 	return true, nil
 }
 
+// parseSecretFile sets value of env var name to content of file at fpath.
+func (es *EnvSet) parseSecretFile(name, fpath string) (bool, error) {
+	if es.parsing[name] {
+		return false, es.failf("env vars %s and %s_FILE are both set", name, name)
+	}
+
+	val, err := option.ReadSecretFile(nil, fpath)
+	if err != nil {
+		return false, es.failf("failed to read env var %s_FILE: %v", name, err)
+	}
+
+	env := es.formal[name]
+	err = option.SetSecret(env.Value, val)
+	if err != nil {
+		return false, es.failf("invalid value read from %s for env var %s: %v", fpath, name, err)
+	}
+
+	// Mark env var as defined and secret.
+	if es.actual == nil {
+		es.actual = make(map[string]*EnvVar)
+	}
+	es.actual[name] = env
+	if es.secrets == nil {
+		es.secrets = make(map[string]bool)
+	}
+	es.secrets[name] = true
+
+	return true, nil
+}
+
+// parseCommand sets value of env var name to the output of command line
+// cmdline.
+func (es *EnvSet) parseCommand(name, cmdline string) (bool, error) {
+	env := es.formal[name]
+	err := option.SetCommand(env.Value, cmdline)
+	if err != nil {
+		return false, es.failf("failed to read env var %s from command: %v", name, err)
+	}
+
+	// Mark env var as defined and secret.
+	if es.actual == nil {
+		es.actual = make(map[string]*EnvVar)
+	}
+	es.actual[name] = env
+	if es.secrets == nil {
+		es.secrets = make(map[string]bool)
+	}
+	es.secrets[name] = true
+
+	return true, nil
+}
+
+// SecretFiles enables reading value of named env vars from files: if env var
+// NAME_FILE is set, value of NAME is read from file at path NAME_FILE, without
+// trailing newline. Setting both NAME and NAME_FILE is an error. If no name is
+// provided, it is enabled for all env vars.
+func (es *EnvSet) SecretFiles(names ...string) {
+	if len(names) == 0 {
+		es.allSecretFiles = true
+		return
+	}
+
+	if es.secretFiles == nil {
+		es.secretFiles = make(map[string]bool)
+	}
+	for _, name := range names {
+		es.secretFiles[name] = true
+	}
+}
+
+// isSecretFile reports whether value of defined env var name can be read from
+// a file.
+func (es *EnvSet) isSecretFile(name string) bool {
+	_, defined := es.formal[name]
+	return defined && (es.allSecretFiles || es.secretFiles[name])
+}
+
+// SecretCommands enables reading value of named env vars from the output of a
+// command set using NAME_CMD=command or NAME=!cmd command. Commands are run by
+// the env var value, which must implement [option.CommandValue], see
+// [configue.Figue.SecretCommands]. Once enabled, "!cmd" values of other env
+// vars are refused.
+func (es *EnvSet) SecretCommands(names ...string) {
+	if es.commands == nil {
+		es.commands = make(map[string]bool)
+	}
+	for _, name := range names {
+		es.commands[name] = true
+	}
+}
+
+// isCommand reports whether value of defined env var name can be read from
+// the output of a command.
+func (es *EnvSet) isCommand(name string) bool {
+	_, defined := es.formal[name]
+	return defined && es.commands[name]
+}
+
+// IsSecret reports whether value of named env var was read from a secret file
+// or command.
+func (es *EnvSet) IsSecret(name string) bool {
+	return es.secrets[name]
+}
+
 // Parsed reports whether EnvSet.Parse has been called.
 func (es *EnvSet) Parsed() bool {
 	return es.parsed
@@ -274,7 +408,7 @@ func (es *EnvSet) PrintDefaults() {
 		if isZero, err := isZeroValue(envVar, envVar.DefValue); err != nil {
 			isZeroValueErrs = append(isZeroValueErrs, err)
 		} else if !isZero {
-			if _, ok := envVar.Value.(*option.String); ok {
+			if _, ok := option.Unwrap(envVar.Value).(*option.String); ok {
 				// put quotes on the value
 				fmt.Fprintf(&b, " (default %q)", envVar.DefValue)
 			} else {
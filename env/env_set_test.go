@@ -4,6 +4,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -255,6 +256,111 @@ func TestEnvSet(t *testing.T) {
 			})
 		})
 	})
+
+	t.Run("SecretFiles", func(t *testing.T) {
+		fpath := filepath.Join(t.TempDir(), "password")
+		err := os.WriteFile(fpath, []byte("s3cr3t\n"), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run("Enabled", func(t *testing.T) {
+			var es EnvSet
+			password := es.String("PASSWORD", "", "")
+			user := es.String("USER", "", "")
+			es.SecretFiles("PASSWORD")
+
+			err := es.Parse([]string{"PASSWORD_FILE=" + fpath, "USER_FILE=" + fpath})
+			if err != nil {
+				t.Fatal("unexpected parse error", err)
+			}
+			if *password != "s3cr3t" || !es.IsSecret("PASSWORD") {
+				t.Fatal("secret not read from file", *password)
+			}
+			if *user != "" || es.IsSecret("USER") {
+				t.Fatal("secret file of non secret env var read", *user)
+			}
+		})
+
+		t.Run("All", func(t *testing.T) {
+			var es EnvSet
+			password := es.String("PASSWORD", "", "")
+			es.SecretFiles()
+
+			err := es.Parse([]string{"PASSWORD_FILE=" + fpath})
+			if err != nil {
+				t.Fatal("unexpected parse error", err)
+			}
+			if *password != "s3cr3t" {
+				t.Fatal("secret not read from file", *password)
+			}
+		})
+
+		t.Run("Errors", func(t *testing.T) {
+			for _, tcase := range []struct {
+				envvars []string
+				err     string
+			}{
+				{[]string{"PORT_FILE=" + fpath}, "invalid value read from " + fpath + " for env var PORT: parse error"},
+				{[]string{"PORT=1", "PORT_FILE=" + fpath}, "env vars PORT and PORT_FILE are both set"},
+				{[]string{"PORT_FILE=/does/not/exist"}, "failed to read env var PORT_FILE: open /does/not/exist: no such file or directory"},
+			} {
+				var es EnvSet
+				es.SetOutput(io.Discard)
+				_ = es.Int("PORT", 0, "")
+				es.SecretFiles()
+
+				err := es.Parse(tcase.envvars)
+				if err == nil || err.Error() != tcase.err {
+					t.Fatalf("unexpected error for %q: %v", tcase.envvars, err)
+				}
+				if strings.Contains(err.Error(), "s3cr3t") {
+					t.Fatal("secret leaked in error", err)
+				}
+			}
+		})
+	})
+
+	t.Run("SecretCommands", func(t *testing.T) {
+		for _, envvars := range [][]string{
+			{"PASSWORD_CMD=pass db"},
+			{"PASSWORD=!cmd pass db"},
+		} {
+			var es EnvSet
+			var password mockCommandVar
+			es.Var(&password, "PASSWORD", "")
+			es.SecretCommands("PASSWORD")
+
+			err := es.Parse(envvars)
+			if err != nil {
+				t.Fatal("unexpected parse error", err)
+			}
+			if password != "cmd: pass db" || !es.IsSecret("PASSWORD") {
+				t.Fatalf("unexpected value for %q: %q", envvars, password)
+			}
+		}
+
+		for _, tcase := range []struct {
+			envvars []string
+			err     string
+		}{
+			{[]string{"USER=!cmd pass user"}, "commands aren't enabled for env var USER"},
+			{[]string{"PORT_CMD=pass port"}, "failed to read env var PORT from command: value can't be read from a command"},
+		} {
+			var es EnvSet
+			es.SetOutput(io.Discard)
+			var password, user mockCommandVar
+			es.Var(&password, "PASSWORD", "")
+			es.Var(&user, "USER", "")
+			_ = es.Int("PORT", 0, "")
+			es.SecretCommands("PASSWORD", "PORT")
+
+			err := es.Parse(tcase.envvars)
+			if err == nil || err.Error() != tcase.err {
+				t.Fatalf("unexpected error for %q: %v", tcase.envvars, err)
+			}
+		}
+	})
 }
 
 func FuzzEnvSet(f *testing.F) {
@@ -267,6 +373,24 @@ func FuzzEnvSet(f *testing.F) {
 	})
 }
 
+// mockCommandVar is a string value recording command lines it is read from.
+type mockCommandVar string
+
+// String implements option.Value.
+func (m *mockCommandVar) String() string { return string(*m) }
+
+// Set implements option.Value.
+func (m *mockCommandVar) Set(str string) error {
+	*m = mockCommandVar(str)
+	return nil
+}
+
+// SetCommand implements option.CommandValue.
+func (m *mockCommandVar) SetCommand(cmdline string) error {
+	*m = mockCommandVar("cmd: " + cmdline)
+	return nil
+}
+
 type mockTextVar struct {
 	str string
 	err error
@@ -15,7 +15,7 @@ func isZeroValue(envvar *EnvVar, value string) (ok bool, err error) {
 	// Build a zero value of the env var's Value type, and see if the
 	// result of calling its String method equals the value passed in.
 	// This works unless the Value type is itself an interface type.
-	typ := reflect.TypeOf(envvar.Value)
+	typ := reflect.TypeOf(option.Unwrap(envvar.Value))
 	var z reflect.Value
 	if typ.Kind() == reflect.Pointer {
 		z = reflect.New(typ.Elem())
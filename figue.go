@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/negrel/configue/option"
 )
@@ -20,6 +22,19 @@ type Figue struct {
 	output        io.Writer
 	Usage         func()
 	errorHandling ErrorHandling
+	// CommandTimeout is the timeout of commands run to get option values. See
+	// [Figue.SecretCommands].
+	CommandTimeout time.Duration
+	commands       map[string]bool
+	decrypter      Decrypter
+	vars           map[string]option.Value
+	defaults       map[string]string
+	defaultErr     error
+	bootstrap      []string
+	literal        map[string]bool
+	pending        map[string][]pendingValue
+	parsing        bool
+	secrets        map[string]bool
 }
 
 // New returns a new Fig instance. This function panics if 0 backend is provided.
@@ -53,37 +68,198 @@ func New(
 // string into a slice of strings by giving the slice the methods of [Value];
 // in particular, [Set] would decompose the comma-separated string into the
 // slice.
+//
+// Values provided by backends are interpolated before being set: "${path}"
+// references value of option at path, "${NAME}" or "${env:NAME}" value of
+// environment variable NAME and "${ref:-default}" uses default if referenced
+// value is unset or empty. "$$" escapes "$". See [Figue.DisableInterpolation].
 func (f *Figue) Var(val option.Value, path string, usage string) {
+	if def, ok := f.defaults[path]; ok {
+		// Invalid default value is reported by Parse.
+		err := setDefault(val, def)
+		if err != nil && f.defaultErr == nil {
+			f.defaultErr = fmt.Errorf("invalid default value %q for option %s: %w", def, path, err)
+		}
+	}
+	if f.vars == nil {
+		f.vars = make(map[string]option.Value)
+	}
+	f.vars[path] = val
+
+	wrapped := &interpolatedValue{Value: val, f: f, path: path}
+	for _, b := range f.backends {
+		b.Var(wrapped, path, usage)
+	}
+}
+
+// BootstrapVar defines a bootstrap option with the specified name and usage
+// string. Bootstrap options are only defined in backends implementing
+// [Bootstrapper] (flags and environment variables) and are resolved, in
+// backends order, before any backend is parsed. They're typically used to
+// configure other backends (e.g. path of a configuration file) by pointing to
+// backends fields.
+func (f *Figue) BootstrapVar(val option.Value, path string, usage string) {
+	f.bootstrap = append(f.bootstrap, path)
+
 	for _, b := range f.backends {
-		b.Var(val, path, usage)
+		if _, ok := b.(Bootstrapper); ok {
+			b.Var(val, path, usage)
+		}
+	}
+}
+
+// ConfigFileVar defines a "config" bootstrap option (-config flag and
+// PREFIX_CONFIG env var) that overrides configuration file path p. p usually
+// points to [Ini.FilePath].
+func (f *Figue) ConfigFileVar(p *string, usage string) {
+	f.BootstrapVar(option.NewString(*p, p), "config", usage)
+}
+
+// ProfileVar defines a "profile" bootstrap option (-profile flag and
+// PREFIX_PROFILE env var) that sets active profiles p. Value is a
+// comma-separated list of profiles, later ones override earlier ones. p usually
+// points to [ini.PropSet.Profiles] of an INI backend.
+func (f *Figue) ProfileVar(p *[]string, usage string) {
+	f.BootstrapVar(profilesValue{p}, "profile", usage)
+}
+
+// profilesValue defines a comma-separated list of profiles. Unlike
+// option.Slice, Set replaces the list instead of appending to it.
+type profilesValue struct {
+	p *[]string
+}
+
+// Set implements option.Value.
+func (v profilesValue) Set(str string) error {
+	var profiles []string
+	for _, profile := range strings.Split(str, ",") {
+		if profile = strings.TrimSpace(profile); profile != "" {
+			profiles = append(profiles, profile)
+		}
+	}
+	*v.p = profiles
+	return nil
+}
+
+// String implements option.Value.
+func (v profilesValue) String() string {
+	if v.p == nil {
+		return ""
 	}
+	return strings.Join(*v.p, ",")
 }
 
-// Parse parses and merges options from their sources. Must be called after all
-// options in the Figue are defined and before options are accessed by the program.
+// Path defines a file system path option with specified name, default value,
+// and usage string. The return value is the address of a string variable that
+// stores the value of the option. See [option.Path].
+func (f *Figue) Path(
+	name string,
+	value string,
+	usage string,
+	checks ...option.PathCheck,
+) *string {
+	t := new(string)
+	f.PathVar(t, name, value, usage, checks...)
+	return t
+}
+
+// PathVar defines a file system path option with specified name, default
+// value, and usage string. The argument p points to a string variable in which
+// to store the value of the option. "~" is expanded, environment variables are
+// referenced with "${NAME}" (or "$NAME" if interpolation is disabled for the
+// option) and relative paths are resolved against the directory of the INI
+// file that set them or the current working directory. checks are performed
+// each time option is set.
+func (f *Figue) PathVar(
+	p *string,
+	name string,
+	value string,
+	usage string,
+	checks ...option.PathCheck,
+) {
+	f.Var(option.NewPath(value, p, checks...), name, usage)
+}
+
+// SecretFiles enables reading value of options at paths from files in
+// backends supporting it: PREFIX_NAME_FILE env vars for [Env] backends,
+// "name_file = path" and "name = @file:path" for INI backends. If no path is
+// provided, it is enabled for all options. Such values are never interpolated
+// and are reported by [Figue.IsSecret].
+func (f *Figue) SecretFiles(paths ...string) {
+	for _, b := range f.backends {
+		if b, ok := b.(interface{ SecretFiles(...string) }); ok {
+			b.SecretFiles(paths...)
+		}
+	}
+}
+
+// IsSecret reports whether value of option at path is secret, that is it was
+// read from a secret file, a command or decrypted. Secret values must not be printed.
+func (f *Figue) IsSecret(path string) bool {
+	return f.secrets[path]
+}
+
+// Parse parses and merges options from their sources. Bootstrap options are
+// resolved first. Must be called after all options in the Figue are defined and
+// before options are accessed by the program.
 func (f *Figue) Parse() error {
+	// Values queued by a previous failed Parse must not be set again.
+	f.pending = nil
+
+	err := f.checkDefaults()
+	if err != nil {
+		return f.fail(err)
+	}
+
+	if len(f.bootstrap) > 0 {
+		for _, b := range f.backends {
+			if b, ok := b.(Bootstrapper); ok {
+				err := b.Bootstrap(f.bootstrap...)
+				if err != nil {
+					return f.fail(err)
+				}
+			}
+		}
+	}
+
+	f.parsing = true
 	for _, b := range f.backends {
 		err := b.Parse()
 		if err != nil {
-			f.usage()
-
-			switch f.errorHandling {
-			case ContinueOnError:
-				return err
-			case ExitOnError:
-				if err == flag.ErrHelp {
-					os.Exit(0)
-				}
-				os.Exit(2)
-			case PanicOnError:
-				panic(err)
-			}
+			f.parsing = false
+			f.pending = nil
+			return f.fail(err)
 		}
 	}
+	f.parsing = false
+
+	err = f.resolvePending()
+	if err != nil {
+		f.pending = nil
+		return f.fail(err)
+	}
 
 	return nil
 }
 
+// fail prints usage message and handles err according to error handling
+// strategy.
+func (f *Figue) fail(err error) error {
+	f.usage()
+
+	switch f.errorHandling {
+	case ExitOnError:
+		if err == flag.ErrHelp {
+			os.Exit(0)
+		}
+		os.Exit(2)
+	case PanicOnError:
+		panic(err)
+	}
+
+	return err
+}
+
 func (f *Figue) defaultUsage() {
 	f.PrintDefaults()
 }
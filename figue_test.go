@@ -0,0 +1,445 @@
+package configue
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/negrel/configue/option"
+)
+
+func TestBootstrap(t *testing.T) {
+	writeConfig := func(t *testing.T, content string) string {
+		fpath := filepath.Join(writeFiles(t, map[string]string{"config.ini": content}), "config.ini")
+		return fpath
+	}
+	setArgs := func(t *testing.T, args ...string) {
+		oldArgs := os.Args
+		os.Args = append([]string{"myapp"}, args...)
+		t.Cleanup(func() { os.Args = oldArgs })
+	}
+	newFigue := func() (*Figue, *Ini, *int, *bool) {
+		ini := NewINI(filepath.Join(t.TempDir(), "default.ini"))
+		f := New("myapp", ContinueOnError, ini, NewEnv("MYAPP"), NewFlag())
+		f.ConfigFileVar(&ini.FilePath, "custom config file path")
+		port := f.Int("db.port", 5432, "database port")
+		debug := f.Bool("debug", false, "enable debug logs")
+		return f, ini, port, debug
+	}
+
+	t.Run("Flag", func(t *testing.T) {
+		fpath := writeConfig(t, "[db]\nport = 5433\n")
+		setArgs(t, "-debug", "-config", fpath, "arg")
+
+		f, ini, port, debug := newFigue()
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if ini.FilePath != fpath {
+			t.Fatal("config file path not bootstrapped", ini.FilePath)
+		}
+		if *port != 5433 || !*debug {
+			t.Fatal("unexpected options values", *port, *debug)
+		}
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		fpath := writeConfig(t, "[db]\nport = 5433\n")
+		setArgs(t, "-db-port=5434")
+		t.Setenv("MYAPP_CONFIG", fpath)
+
+		f, ini, port, _ := newFigue()
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if ini.FilePath != fpath {
+			t.Fatal("config file path not bootstrapped", ini.FilePath)
+		}
+		if *port != 5434 {
+			t.Fatal("flag doesn't override config file", *port)
+		}
+	})
+
+	t.Run("FlagOverEnv", func(t *testing.T) {
+		fpath := writeConfig(t, "[db]\nport = 5433\n")
+		setArgs(t, "--config="+fpath)
+		t.Setenv("MYAPP_CONFIG", "/does/not/exist.ini")
+
+		f, ini, port, _ := newFigue()
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if ini.FilePath != fpath || *port != 5433 {
+			t.Fatal("flag doesn't override env var", ini.FilePath, *port)
+		}
+	})
+
+	t.Run("StopAtNonFlag", func(t *testing.T) {
+		setArgs(t, "arg", "-config", "/does/not/exist.ini")
+
+		f, ini, _, _ := newFigue()
+		defaultPath := ini.FilePath
+		_ = f.Parse()
+		if ini.FilePath != defaultPath {
+			t.Fatal("flag after non-flag argument bootstrapped", ini.FilePath)
+		}
+	})
+
+	t.Run("NotInINI", func(t *testing.T) {
+		setArgs(t)
+
+		_, ini, _, _ := newFigue()
+		if ini.Lookup("config") != nil {
+			t.Fatal("bootstrap option defined in INI backend")
+		}
+	})
+}
+
+func TestProfile(t *testing.T) {
+	fpath := filepath.Join(writeFiles(t, map[string]string{"config.ini": "[db]\nhost = localhost\n[@staging db]\nhost = staging\n[@prod db]\nhost = prod\n"}), "config.ini")
+	oldArgs := os.Args
+	os.Args = []string{"myapp", "-profile", "staging,prod"}
+	t.Cleanup(func() { os.Args = oldArgs })
+
+	var output strings.Builder
+	ini := NewINI(fpath)
+	f := New("myapp", ContinueOnError, ini, NewEnv("MYAPP"), NewFlag())
+	f.SetOutput(&output)
+	f.ProfileVar(&ini.Profiles, "active configuration profiles")
+	host := f.String("db.host", "", "database host")
+
+	err := f.Parse()
+	if err != nil {
+		t.Fatal("unexpected parse error", err)
+	}
+	if *host != "prod" {
+		t.Fatal("profile not applied", *host)
+	}
+	if loc, _ := ini.Provenance("db.host"); loc.Profile != "prod" {
+		t.Fatal("unexpected provenance", loc)
+	}
+
+	f.PrintDefaults()
+	if !strings.Contains(output.String(), "Available profiles: staging, prod\n") {
+		t.Fatal("available profiles not printed:", output.String())
+	}
+	if !strings.Contains(output.String(), "MYAPP_PROFILE") {
+		t.Fatal("profile env var not printed:", output.String())
+	}
+}
+
+func TestPathOption(t *testing.T) {
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "etc", "config.ini")
+	err := os.MkdirAll(filepath.Join(dir, "etc", "certs"), 0o700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "etc", "certs", "server.pem"), nil, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(fpath, []byte("[tls]\ncert = certs/server.pem\ndata_dir = ${HOME}/data\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldArgs := os.Args
+	os.Args = []string{"myapp", "-tls-key", "key.pem"}
+	t.Cleanup(func() { os.Args = oldArgs })
+	t.Setenv("HOME", "/home/user")
+
+	f := New("myapp", ContinueOnError, NewINI(fpath), NewEnv("MYAPP"), NewFlag())
+	f.SetOutput(&strings.Builder{})
+	cert := f.Path("tls.cert", "", "TLS certificate", option.PathIsFile, option.PathReadable)
+	key := f.Path("tls.key", "", "TLS key")
+	dataDir := f.Path("tls.data_dir", "~/.myapp", "data directory")
+
+	err = f.Parse()
+	if err != nil {
+		t.Fatal("unexpected parse error", err)
+	}
+	if *cert != filepath.Join(dir, "etc", "certs", "server.pem") {
+		t.Fatal("path not resolved against INI file directory", *cert)
+	}
+	cwd, _ := os.Getwd()
+	if *key != filepath.Join(cwd, "key.pem") {
+		t.Fatal("path not resolved against working directory", *key)
+	}
+	if *dataDir != "/home/user/data" {
+		t.Fatal("unexpected data dir", *dataDir)
+	}
+
+	err = f.Set("tls.cert", "missing.pem")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret")
+	err := os.WriteFile(secret, []byte("p4$${HOME}\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fpath := filepath.Join(dir, "config.ini")
+	err = os.WriteFile(fpath, []byte("[db]\npassword = ${HOME}\napi_key_file = secret\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldArgs := os.Args
+	os.Args = []string{"myapp"}
+	t.Cleanup(func() { os.Args = oldArgs })
+	t.Setenv("MYAPP_DB_PASSWORD_FILE", secret)
+
+	f := New("myapp", ContinueOnError, NewINI(fpath), NewEnv("MYAPP"), NewFlag())
+	f.SetOutput(&strings.Builder{})
+	password := f.String("db.password", "", "database password")
+	apiKey := f.String("db.api_key", "", "API key")
+	user := f.String("db.user", "", "database user")
+	f.SecretFiles()
+
+	err = f.Parse()
+	if err != nil {
+		t.Fatal("unexpected parse error", err)
+	}
+	if *password != "p4$${HOME}" || !f.IsSecret("db.password") {
+		t.Fatal("secret not read from file", *password)
+	}
+	if *apiKey != "p4$${HOME}" || !f.IsSecret("db.api_key") {
+		t.Fatal("secret not read from file", *apiKey)
+	}
+	if *user != "" || f.IsSecret("db.user") {
+		t.Fatal("unexpected user", *user)
+	}
+}
+
+func TestSecretCommands(t *testing.T) {
+	dir := t.TempDir()
+	script := func(name, content string) string {
+		fpath := filepath.Join(dir, name)
+		err := os.WriteFile(fpath, []byte("#!/bin/sh\n"+content), 0o700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fpath
+	}
+	pass := script("pass", "echo \"p4\\$\\${$1}\"\n")
+	fail := script("fail", "echo \"entry $1 not found\" >&2\nexit 1\n")
+	slow := script("slow", "sleep 5\n")
+	port := script("port", "echo 80a\n")
+
+	oldArgs := os.Args
+	os.Args = []string{"myapp"}
+	t.Cleanup(func() { os.Args = oldArgs })
+
+	setup := func(t *testing.T, config string) (*Figue, map[string]*string) {
+		fpath := filepath.Join(writeFiles(t, map[string]string{"config.ini": config}), "config.ini")
+
+		f := New("myapp", ContinueOnError, NewINI(fpath), NewEnv("MYAPP"), NewFlag())
+		f.SetOutput(io.Discard)
+		values := map[string]*string{
+			"db.password": f.String("db.password", "", "database password"),
+			"db.user":     f.String("db.user", "", "database user"),
+			"api.key":     f.String("api.key", "", "API key"),
+		}
+		f.SecretCommands("db.password", "api.key")
+		return f, values
+	}
+
+	t.Run("Forms", func(t *testing.T) {
+		t.Setenv("MYAPP_API_KEY_CMD", pass+" api")
+		f, values := setup(t, "[db]\npassword_cmd = "+pass+" db/prod\n")
+
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *values["db.password"] != "p4$${db/prod}" || !f.IsSecret("db.password") {
+			t.Fatal("unexpected password", *values["db.password"])
+		}
+		if *values["api.key"] != "p4$${api}" || !f.IsSecret("api.key") {
+			t.Fatal("unexpected API key", *values["api.key"])
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		f, values := setup(t, "[db]\nuser = !cmd "+pass+" user\n")
+
+		err := f.Parse()
+		if err == nil || !strings.HasSuffix(err.Error(), "commands aren't enabled for property db.user") {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *values["db.user"] != "" {
+			t.Fatal("unexpected user", *values["db.user"])
+		}
+	})
+
+	t.Run("Untrusted", func(t *testing.T) {
+		marker := filepath.Join(dir, "pwned")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, `{"db": {"password": "!cmd touch `+marker+`"}}`)
+		}))
+		defer server.Close()
+
+		f := New("myapp", ContinueOnError, NewHTTP(server.URL, HTTPOptions{}))
+		f.SetOutput(io.Discard)
+		password := f.String("db.password", "", "database password")
+		f.SecretCommands("db.password")
+
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		// Remote values are never run.
+		if *password != "!cmd touch "+marker || f.IsSecret("db.password") {
+			t.Fatal("unexpected password", *password)
+		}
+		if _, err := os.Stat(marker); !os.IsNotExist(err) {
+			t.Fatal("command of remote value run", err)
+		}
+	})
+
+	t.Run("Quoting", func(t *testing.T) {
+		f, values := setup(t, "[db]\npassword_cmd = "+pass+" 'db/my prod'\n[api]\nkey = !cmd "+pass+" \"a \\\"b\\\"\" c\\ d\n")
+
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *values["db.password"] != "p4$${db/my prod}" {
+			t.Fatal("unexpected password", *values["db.password"])
+		}
+		if *values["api.key"] != `p4$${a "b"}` {
+			t.Fatal("unexpected API key", *values["api.key"])
+		}
+
+		f, _ = setup(t, "[db]\npassword_cmd = "+pass+" 'db/prod\n")
+		err = f.Parse()
+		if err == nil || !strings.HasSuffix(err.Error(), "unclosed ' quote") {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("Stderr", func(t *testing.T) {
+		f, _ := setup(t, "[db]\npassword = !cmd "+fail+" db/prod\n")
+
+		err := f.Parse()
+		if err == nil || !strings.HasSuffix(err.Error(), "exit status 1: entry db/prod not found") {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		f, _ := setup(t, "[db]\npassword = !cmd "+slow+"\n")
+		f.CommandTimeout = 10 * time.Millisecond
+
+		err := f.Parse()
+		if err == nil || !strings.Contains(err.Error(), "timed out after 10ms") {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("Redaction", func(t *testing.T) {
+		fpath := filepath.Join(writeFiles(t, map[string]string{"config.ini": "port = !cmd " + port + "\n"}), "config.ini")
+		f := New("myapp", ContinueOnError, NewINI(fpath))
+		f.SetOutput(io.Discard)
+		_ = f.Int("port", 0, "")
+		f.SecretCommands("port")
+
+		err := f.Parse()
+		if err == nil || strings.Contains(err.Error(), "80a") {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+}
+
+func TestDecrypt(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	b64Key := base64.StdEncoding.EncodeToString(key)
+	encrypt := func(plaintext string) string {
+		encrypted, err := option.EncryptAESGCM(key, plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return encrypted
+	}
+
+	oldArgs := os.Args
+	os.Args = []string{"myapp"}
+	t.Cleanup(func() { os.Args = oldArgs })
+
+	setup := func(t *testing.T, config string, keyFunc KeyFunc) (*Figue, *string, *int) {
+		fpath := filepath.Join(writeFiles(t, map[string]string{"config.ini": config}), "config.ini")
+
+		f := New("myapp", ContinueOnError, NewINI(fpath), NewEnv("MYAPP"), NewFlag())
+		f.SetOutput(io.Discard)
+		password := f.String("db.password", "", "database password")
+		port := f.Int("db.port", 0, "database port")
+		f.Decrypt(AESGCM(keyFunc))
+		return f, password, port
+	}
+
+	t.Run("KeyFile", func(t *testing.T) {
+		keyFile := filepath.Join(t.TempDir(), "key")
+		err := os.WriteFile(keyFile, []byte(b64Key+"\n"), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("MYAPP_DB_PORT", encrypt("5433"))
+		f, password, port := setup(t, "[db]\npassword = "+encrypt("p4$${HOME}")+"\n", KeyFile(keyFile))
+
+		err = f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *password != "p4$${HOME}" || !f.IsSecret("db.password") {
+			t.Fatal("unexpected password", *password)
+		}
+		if *port != 5433 || !f.IsSecret("db.port") {
+			t.Fatal("unexpected port", *port)
+		}
+	})
+
+	t.Run("KeyEnv", func(t *testing.T) {
+		t.Setenv("MYAPP_KEY", b64Key)
+		f, password, _ := setup(t, "[db]\npassword = "+encrypt("p4ssw0rd")+"\n", KeyEnv("MYAPP_KEY"))
+
+		err := f.Parse()
+		if err != nil {
+			t.Fatal("unexpected parse error", err)
+		}
+		if *password != "p4ssw0rd" {
+			t.Fatal("unexpected password", *password)
+		}
+	})
+
+	t.Run("WrongKey", func(t *testing.T) {
+		wrongKey := func() ([]byte, error) { return []byte("fedcba9876543210fedcba9876543210"), nil }
+		f, _, _ := setup(t, "[db]\npassword = "+encrypt("p4ssw0rd")+"\n", wrongKey)
+
+		err := f.Parse()
+		if err == nil || !strings.Contains(err.Error(), "failed to decrypt value of option db.password") ||
+			strings.Contains(err.Error(), "p4ssw0rd") {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+
+	t.Run("InvalidValue", func(t *testing.T) {
+		f, _, _ := setup(t, "[db]\nport = "+encrypt("p4ssw0rd")+"\n", func() ([]byte, error) { return key, nil })
+
+		err := f.Parse()
+		if err == nil || strings.Contains(err.Error(), "p4ssw0rd") {
+			t.Fatal("unexpected parse error", err)
+		}
+	})
+}
@@ -3,8 +3,11 @@ package configue
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"path"
+	"path/filepath"
+
+	"github.com/negrel/configue/option"
 )
 
 // ErrorHandling defines how [Figue.Parse] behaves if the parse fails.
@@ -57,6 +60,26 @@ func Parse() error {
 	return CommandLine.Parse()
 }
 
+// Defaults reads default values of command-line options from r. See
+// [Figue.Defaults] for more details.
+func Defaults(r io.Reader, format Format) error {
+	return CommandLine.Defaults(r, format)
+}
+
+// Path defines a file system path option with specified name, default value,
+// and usage string. The return value is the address of a string variable that
+// stores the value of the option. See [Figue.PathVar].
+func Path(name string, value string, usage string, checks ...option.PathCheck) *string {
+	return CommandLine.Path(name, value, usage, checks...)
+}
+
+// PathVar defines a file system path option with specified name, default
+// value, and usage string. The argument p points to a string variable in which
+// to store the value of the option. See [Figue.PathVar].
+func PathVar(p *string, name string, value string, usage string, checks ...option.PathCheck) {
+	CommandLine.PathVar(p, name, value, usage, checks...)
+}
+
 // UserDir returns user configuration directory or provided default on error
 // (e.g. when os.UserConfigDir fails).
 func UserDir(def string) string {
@@ -68,13 +91,13 @@ func UserDir(def string) string {
 }
 
 // AppDir returns application configuration directory which is [UserDir] joined
-// with os.Args[0].
+// with os.Args[0]. See [Paths] for a resolver with an explicit application name.
 func AppDir(defaultUserDir string) string {
-	return path.Join(UserDir(defaultUserDir), path.Base(os.Args[0]))
+	return filepath.Join(UserDir(defaultUserDir), filepath.Base(os.Args[0]))
 }
 
 // File returns path to configuration file by joining [AppDir] with provided
 // `fname`.
 func File(defaultUserDir, fname string) string {
-	return path.Join(AppDir(defaultUserDir), fname)
+	return filepath.Join(AppDir(defaultUserDir), fname)
 }
@@ -0,0 +1,100 @@
+package ini
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// Condition defines a condition of a conditional section: value of runtime
+// fact Fact must match glob Pattern (see [path.Match]).
+type Condition struct {
+	Fact, Pattern string
+}
+
+// String returns condition formatted as "fact=pattern".
+func (c Condition) String() string {
+	return c.Fact + "=" + c.Pattern
+}
+
+// Evaluator reports whether condition of a conditional section holds.
+type Evaluator func(Condition) (bool, error)
+
+// DefaultEvaluator matches conditions against runtime facts:
+//   - "host" is the host name reported by os.Hostname
+//   - "os" is runtime.GOOS
+//   - "arch" is runtime.GOARCH
+//   - "env:NAME" is the value of environment variable NAME
+//
+// Conditions on unset environment variables never hold.
+func DefaultEvaluator(cond Condition) (bool, error) {
+	return NewEvaluator(nil)(cond)
+}
+
+// NewEvaluator returns an Evaluator that matches conditions against facts,
+// falling back to runtime facts of [DefaultEvaluator] for missing ones. It is
+// mostly useful for testing.
+func NewEvaluator(facts map[string]string) Evaluator {
+	return func(cond Condition) (bool, error) {
+		value, ok := facts[cond.Fact]
+		if !ok {
+			var err error
+			value, ok, err = runtimeFact(cond.Fact)
+			if err != nil {
+				return false, err
+			}
+		}
+		if !ok {
+			return false, nil
+		}
+
+		return path.Match(cond.Pattern, value)
+	}
+}
+
+// runtimeFact returns value of named runtime fact. It returns false if fact
+// is an unset environment variable.
+func runtimeFact(name string) (string, bool, error) {
+	switch name {
+	case "host":
+		host, err := os.Hostname()
+		return host, err == nil, err
+	case "os":
+		return runtime.GOOS, true, nil
+	case "arch":
+		return runtime.GOARCH, true, nil
+	}
+
+	if envName, ok := strings.CutPrefix(name, "env:"); ok && envName != "" {
+		value, ok := os.LookupEnv(envName)
+		return value, ok, nil
+	}
+
+	return "", false, fmt.Errorf("unknown fact %q", name)
+}
+
+// cutConditions splits conditional section name "if fact=pattern,... section"
+// into conditions and section. ok is false if name isn't a conditional section.
+func cutConditions(name string) (conds []Condition, section string, ok bool, err error) {
+	after, found := strings.CutPrefix(name, "if ")
+	if !found {
+		return nil, name, false, nil
+	}
+
+	after = strings.TrimSpace(after)
+	list, section, _ := strings.Cut(after, " ")
+	for _, str := range strings.Split(list, ",") {
+		fact, pattern, found := strings.Cut(str, "=")
+		if !found || fact == "" {
+			return nil, "", true, fmt.Errorf("invalid condition %q", str)
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, "", true, fmt.Errorf("invalid condition %q: %w", str, err)
+		}
+		conds = append(conds, Condition{Fact: fact, Pattern: pattern})
+	}
+
+	return conds, strings.TrimSpace(section), true, nil
+}
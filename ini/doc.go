@@ -43,5 +43,70 @@ types.
 
 The default set of command-line properties is controlled by top-level functions.
 The [PropSet] type allows one to define independent sets of properties.
+
+# Include directives
+
+Top-level "include = path" directives parse another INI file in place, so
+properties defined after the directive override included ones. Relative paths
+are resolved against the directory of the including file. "include? = path"
+does the same but ignores missing files.
+
+	include = base.ini
+	include? = local.ini
+
+# Profiles
+
+Profile sections "[@name section]" contain properties only set if profile is
+listed in [PropSet.Profiles]. They overlay other properties of the document
+regardless of their position, and active profiles override each other in
+order.
+
+	[db]
+	host = localhost
+
+	[@prod db]
+	host = db.example.com
+
+# Conditional sections
+
+Conditional sections "[if fact=pattern section]" contain properties only set if
+runtime fact matches glob pattern (see [path.Match]). Multiple comma-separated
+conditions must all hold. Supported facts are "host", "os", "arch" and
+"env:NAME", see [DefaultEvaluator].
+
+	[if host=web-*,os=linux http]
+	port = 80
+
+	[if env:REGION=eu db]
+	host = db.eu.example.com
+
+# Section inheritance
+
+Section header "[name : base]" makes section name inherit all properties,
+including those of subsections, of section base parsed so far. Inherited
+properties can be overridden in the section. Base section must be defined
+before the inheriting one and can't be one of its parents.
+
+	[db.primary]
+	host = db1.example.com
+	port = 5432
+
+	[db.replica : db.primary]
+	host = db2.example.com
+
+# Secret files
+
+Once enabled with [PropSet.SecretFiles], values of properties can be read from
+files, such as Docker and Kubernetes secrets. Relative paths are resolved
+against the directory of the INI file.
+
+	[db]
+	password_file = /run/secrets/db_password
+	api_key = @file:/run/secrets/api_key
+
+# Tokenizer
+
+[Scanner] exposes the tokenizer used by [PropSet] so tools such as linters and
+formatters can process INI documents exactly as they are parsed at runtime.
 */
 package ini
@@ -708,7 +708,7 @@ func UnquoteUsage(optValue option.Value, optUsage string) (name string, usage st
 	}
 	// No explicit name, so use type if we can find one.
 	name = "value"
-	switch optValue.(type) {
+	switch option.Unwrap(optValue).(type) {
 	case *option.Bool:
 		name = ""
 	case *option.Duration:
@@ -721,6 +721,8 @@ func UnquoteUsage(optValue option.Value, optUsage string) (name string, usage st
 		name = "string"
 	case *option.Uint, *option.Uint64:
 		name = "uint"
+	case *option.Path:
+		name = "path"
 	}
 	return
 }
@@ -1,257 +1,317 @@
 package ini
 
 import (
-	"bufio"
-	"bytes"
 	"fmt"
 	"io"
-	"regexp"
-	"strconv"
+	"path/filepath"
 	"strings"
-	"unicode"
 )
 
-var (
-	sectionRegex = regexp.MustCompile(`\.?\w+(.\w+)?`)
-)
-
-// parser defines a parser for the INI format.
+// parser defines a parser for the INI format. It resolves sections of tokens
+// returned by a Scanner into key value pairs.
 type parser struct {
-	scanner   *bufio.Scanner
-	section   string
-	line, col int
-	buf       []byte
-}
-
-func newParser(r io.Reader) *parser {
-	scanner := bufio.NewScanner(r)
-	scanner.Split(bufio.ScanLines)
-
-	return &parser{scanner: scanner, section: "", line: 0}
-}
-
-func (p *parser) nextLine() bool {
-	if p.scanner.Scan() {
-		p.line++
-		p.col = 0
-		p.buf = p.scanner.Bytes()
-	} else {
-		p.buf = nil
-		p.col = 0
-	}
-
-	return p.buf != nil
+	scanner *Scanner
+	section string
+	// Profile of current section, if any.
+	profile string
+	// Evaluator of conditional sections and whether current section
+	// conditions don't hold.
+	eval Evaluator
+	skip bool
+	// Sections parsed so far, shared with parsers of included files, and
+	// pending key value pairs inherited by current section.
+	sections  *sectionTable
+	inherited []KeyValue
+	// Position of last parsed key and value.
+	keyPos, valuePos Position
+	// Parser of file including this one, if any.
+	parent *parser
 }
 
-func (p *parser) trimSpace() {
-	buf := p.bytes()
-	trimmed := bytes.TrimLeftFunc(buf, unicode.IsSpace)
-	p.skip(len(buf) - len(trimmed))
-	trimmed = bytes.TrimRightFunc(buf, unicode.IsSpace)
-	p.buf = p.buf[:len(p.buf)-(len(buf)-len(trimmed))]
+// KeyValue defines a key value pair of an INI document. Key includes section
+// of the pair.
+type KeyValue struct {
+	Key, Value string
+	Pos        Position
+	// Profile is the name of the profile section of the pair, if any.
+	Profile string
 }
 
-func (p *parser) bytes() []byte {
-	if len(p.buf) <= p.col {
-		return nil
+// ReadAll reads all key value pairs of INI document r in order. Unlike
+// [PropSet.Parse], keys don't need to be defined and include directives are
+// not processed. Conditional sections are evaluated using [DefaultEvaluator].
+func ReadAll(r io.Reader) ([]KeyValue, error) {
+	var kvs []KeyValue
+
+	p := newParser(r)
+	for {
+		key, value, err := p.parseNext()
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			return kvs, nil
+		}
+		if p.skip {
+			continue
+		}
+		kvs = append(kvs, KeyValue{Key: key, Value: value, Pos: p.valuePos, Profile: p.profile})
 	}
-	return p.buf[p.col:]
 }
 
-func (p *parser) peek() byte {
-	return p.buf[p.col]
-}
-
-func (p *parser) empty() bool {
-	return p.col >= len(p.buf)
-}
-
-// Move cursor by n bytes. If n > len(p.bytes()) cursor is move to end.
-func (p *parser) skip(n int) {
-	p.col += min(n, len(p.bytes()))
+func newParser(r io.Reader) *parser {
+	return &parser{scanner: NewScanner(r), section: "", sections: &sectionTable{}}
 }
 
-// Remove trailing comment from current buffer.
-func (p *parser) trimComment() {
-	if i := bytes.IndexAny(p.bytes(), ";#"); i != -1 {
-		p.buf = p.buf[:p.col+i]
-	}
+// maxInheritedPairs is the maximum number of key value pairs inherited by
+// sections of a document. It prevents small documents from expanding into
+// billions of pairs.
+const maxInheritedPairs = 1 << 16
+
+// pairChunkSize is the number of pairs of sectionTable chunks.
+const pairChunkSize = 1024
+
+// sectionTable records sections and key value pairs parsed so far for section
+// inheritance.
+type sectionTable struct {
+	defined map[string]bool
+	bases   map[string]string
+	// Recorded pairs are split in chunks so they're never copied as document
+	// grows.
+	pairs     [][]sectionPair
+	inherited int
 }
 
-// Returns a slice of current buffer if any Unicode code point from `str` is
-// found.
-func (p *parser) sliceAny(str string) []byte {
-	i := bytes.IndexAny(p.bytes(), str)
-	if i == -1 {
-		return nil
-	}
-	return p.bytes()[:i]
+// sectionPair is a key value pair recorded for section inheritance.
+type sectionPair struct {
+	key, value string
+	// inherited is true if pair was inherited from another section.
+	inherited bool
 }
 
+// parseNext returns next key value pair. Empty key, value and nil error are
+// returned at the end of input.
 func (p *parser) parseNext() (string, string, error) {
-	if p.nextLine() {
-		p.trimSpace()
-		if p.empty() {
-			return p.parseNext()
-		}
-
-		// Parse section.
-		if p.peek() == '[' {
-			p.trimComment()
-
-			// Skip '['
-			p.skip(1)
-
-			// Extract section.
-			section := p.sliceAny("]")
-			if section == nil {
-				return "", "", p.error("invalid section")
-			}
-			p.skip(len(section) + 1)
-			p.trimSpace()
-
-			if !p.empty() {
-				return "", "", p.error("invalid content after section")
-			}
+	if len(p.inherited) > 0 {
+		kv := p.inherited[0]
+		p.inherited = p.inherited[1:]
+		p.keyPos, p.valuePos = kv.Pos, kv.Pos
+		p.record(kv.Key, kv.Value, true)
+		return kv.Key, kv.Value, nil
+	}
 
-			if len(section) > 0 && !sectionRegex.Match(section) {
-				return "", "", p.error("invalid section")
-			}
+	var key string
 
+	for p.scanner.Scan() {
+		tok := p.scanner.Token()
+		switch tok.Kind {
+		case SectionHeader:
+			section := strings.TrimSpace(tok.Text)
 			if len(section) == 0 {
 				p.section = ""
+				p.profile = ""
+				p.skip = false
 			} else if section[0] == '.' {
-				p.section += string(section[1:]) + "."
+				p.section += section[1:] + "."
 			} else {
-				p.section = string(section) + "."
-			}
-
-			return p.parseNext()
-		}
+				conds, section, ok, err := cutConditions(section)
+				if err != nil {
+					return "", "", p.errorf(tok.Pos, "%w", err)
+				}
+				p.skip = false
+				if ok {
+					p.skip, err = p.evalConditions(conds)
+					if err != nil {
+						return "", "", p.errorf(tok.Pos, "%w", err)
+					}
+				}
 
-		// Skip comments.
-		if p.peek() == ';' || p.peek() == '#' {
-			return p.parseNext()
-		}
+				profile, section, ok := cutProfile(section)
+				if ok && profile == "" {
+					return "", "", p.errorf(tok.Pos, "invalid profile section")
+				}
+				name, base, inherits := strings.Cut(section, ":")
+				name = strings.TrimSpace(name)
+				if inherits {
+					err := p.inherit(name, strings.TrimSpace(base), tok.Pos)
+					if err != nil {
+						return "", "", err
+					}
+				}
 
-		// Parse key = val
-		{
-			key := p.sliceAny("=:")
-			if key == nil {
-				return "", "", p.error("invalid option, separators '=' or ':' are missing")
+				p.profile = profile
+				p.section = ""
+				if name != "" {
+					p.section = name + "."
+				}
+				if !p.skip && p.profile == "" {
+					if p.sections.defined == nil {
+						p.sections.defined = make(map[string]bool)
+					}
+					p.sections.defined[name] = true
+				}
+				if len(p.inherited) > 0 {
+					return p.parseNext()
+				}
 			}
-			p.skip(len(key) + 1)
 
-			key = bytes.TrimSpace(key)
+		case Key:
+			key = p.section + tok.Text
+			p.keyPos = tok.Pos
 
-			value, err := p.parseValue()
-			if err != nil {
-				return "", "", err
-			}
-
-			return p.section + string(key), value, nil
+		case Value:
+			p.valuePos = tok.Pos
+			p.record(key, tok.Text, false)
+			return key, tok.Text, nil
 		}
-	} else if err := p.scanner.Err(); err != nil {
-		return "", "", err
 	}
 
-	return "", "", nil
+	err := p.scanner.Err()
+	if e, ok := err.(*Error); ok {
+		e.IncludedFrom = p.includedFrom()
+	}
+	return "", "", err
 }
 
-func (p *parser) parseValue() (string, error) {
-	p.trimComment()
-	p.trimSpace()
-	if p.empty() {
-		return "", nil
+// record records key value pair for section inheritance. Pairs of profile
+// sections and unmatched conditional sections are ignored.
+func (p *parser) record(key, value string, inherited bool) {
+	if p.skip || p.profile != "" {
+		return
 	}
 
-	if b := p.peek(); b == '"' || b == '\'' || b == '`' {
-		return p.parseString()
+	pairs := p.sections.pairs
+	if len(pairs) == 0 || len(pairs[len(pairs)-1]) == pairChunkSize {
+		pairs = append(pairs, make([]sectionPair, 0, pairChunkSize))
 	}
+	last := &pairs[len(pairs)-1]
+	*last = append(*last, sectionPair{key, value, inherited})
+	p.sections.pairs = pairs
+}
 
-	line := p.bytes()
-	if len(line) == 0 || line[len(line)-1] != '\\' {
-		// Single line unquoted string.
-		return string(line), nil
+// inherit queues key value pairs of section base, parsed so far, as pairs of
+// section name. Inherited pairs are located at pos, the position of the
+// section header.
+func (p *parser) inherit(name, base string, pos Position) error {
+	if name == "" || base == "" {
+		return p.errorf(pos, "invalid section inheritance")
 	}
 
-	// Multi line unquoted string
-	var b strings.Builder
-	_, _ = b.Write(line[:len(line)-1])
-	_ = b.WriteByte('\n')
-	for p.nextLine() {
-		p.trimComment()
-		p.trimSpace()
-		line = p.bytes()
-		if len(line) == 0 || line[len(line)-1] != '\\' {
-			_, _ = b.Write(line)
+	// Detect cycles.
+	chain := []string{name, base}
+	for cur := base; cur != name; {
+		next, ok := p.sections.bases[cur]
+		if !ok {
 			break
 		}
-		_, _ = b.Write(line[:len(line)-1])
-		_ = b.WriteByte('\n')
+		chain = append(chain, next)
+		cur = next
 	}
-	if p.scanner.Err() != nil {
-		return "", p.scanner.Err()
+	if chain[len(chain)-1] == name {
+		return p.errorf(pos, "section inheritance cycle: %v", strings.Join(chain, " -> "))
 	}
 
-	return b.String(), nil
-}
-
-func (p *parser) parseString() (string, error) {
-	var b strings.Builder
+	// Sections can't inherit from their ancestors, that would inherit their own
+	// pairs again.
+	if strings.HasPrefix(name, base+".") {
+		return p.errorf(pos, "section %v can't inherit from its parent section %v", name, base)
+	}
 
-	if len(p.bytes()) < 2 {
-		return "", p.error("invalid string")
+	if !p.sections.defined[base] {
+		return p.errorf(pos, "section %v inherits from section %v which isn't defined before it", name, base)
 	}
 
-	quote := p.peek()
-	_ = b.WriteByte(quote)
-	p.skip(1)
-
-	inner := p.sliceAny(string(quote))
-	if inner == nil {
-		if quote != '`' {
-			return "", p.error("unclosed string")
-		} else {
-			_, _ = b.Write(p.bytes())
-			_ = b.WriteByte('\n')
-
-			// Read until we find end of string.
-			var l []byte
-			for p.nextLine() {
-				l = p.sliceAny("`")
-				if l != nil {
-					_, _ = b.Write(l)
-					_ = b.WriteByte(quote)
-					p.skip(len(l) + 1)
-					break
-				}
+	if !p.skip {
+		if p.sections.bases == nil {
+			p.sections.bases = make(map[string]string)
+		}
+		p.sections.bases[name] = base
+	}
 
-				_, _ = b.Write(p.bytes())
-				_ = b.WriteByte('\n')
-			}
-			if p.scanner.Err() != nil {
-				return "", p.scanner.Err()
+	prefix := base + "."
+	for _, chunk := range p.sections.pairs {
+		for _, kv := range chunk {
+			rest, ok := strings.CutPrefix(kv.key, prefix)
+			// Pairs already inherited by section aren't inherited again.
+			if !ok || kv.inherited && strings.HasPrefix(kv.key, name+".") {
+				continue
 			}
-			if str := b.String(); len(str) > 0 && str[len(str)-1] != quote {
-				return "", p.error("unclosed multiline string")
+			if p.sections.inherited == maxInheritedPairs {
+				return p.errorf(pos, "section %v inherits too many properties (more than %v)", name, maxInheritedPairs)
 			}
+			p.sections.inherited++
+			p.inherited = append(p.inherited, KeyValue{Key: name + "." + rest, Value: kv.value, Pos: pos})
 		}
-	} else {
-		_, _ = b.Write(inner)
-		_ = b.WriteByte(quote)
-		p.skip(len(inner) + 1)
 	}
 
-	p.trimComment()
-	p.trimSpace()
-	if !p.empty() {
-		return "", p.error("invalid content after value")
+	return nil
+}
+
+// evalConditions returns true if one of conds doesn't hold.
+func (p *parser) evalConditions(conds []Condition) (bool, error) {
+	eval := p.eval
+	if eval == nil {
+		eval = DefaultEvaluator
 	}
 
-	return strconv.Unquote(b.String())
+	for _, cond := range conds {
+		ok, err := eval(cond)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// cutProfile splits profile section name "@profile section" into profile
+// name and section. ok is false if name isn't a profile section.
+func cutProfile(name string) (profile, section string, ok bool) {
+	if after, found := strings.CutPrefix(name, "@"); found {
+		profile, section, _ = strings.Cut(after, " ")
+		return profile, strings.TrimSpace(section), true
+	}
+	return "", name, false
+}
+
+// includedFrom returns locations of include directives that led to this
+// parser, innermost first.
+func (p *parser) includedFrom() []Location {
+	var locations []Location
+	for parent := p.parent; parent != nil; parent = parent.parent {
+		locations = append(locations, Location{
+			Filename: parent.scanner.Filename,
+			Pos:      parent.valuePos,
+		})
+	}
+	return locations
 }
 
-func (p *parser) error(msg string) error {
-	return fmt.Errorf("%v at %v:%v", msg, p.line, p.col+1)
+// includes reports whether file at fpath is parsed by this parser or one of
+// its parents.
+func (p *parser) includes(fpath string) bool {
+	abs, err := filepath.Abs(fpath)
+	if err != nil {
+		return false
+	}
+
+	for ; p != nil; p = p.parent {
+		if parsed, err := filepath.Abs(p.scanner.Filename); err == nil && parsed == abs {
+			return true
+		}
+	}
+	return false
+}
+
+// errorf returns an [Error] located at pos.
+func (p *parser) errorf(pos Position, format string, a ...any) error {
+	return &Error{
+		Filename: p.scanner.Filename,
+		Pos:      pos,
+		Err:      fmt.Errorf(format, a...),
+
+		IncludedFrom: p.includedFrom(),
+	}
 }
@@ -1,6 +1,7 @@
 package ini
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -114,7 +115,7 @@ b ; foo`,
 			output: [][2]string{
 				{},
 			},
-			err: "invalid section at 1:4",
+			err: "1:2: invalid section",
 		},
 		{
 			name:  "InvalidString",
@@ -122,7 +123,7 @@ b ; foo`,
 			output: [][2]string{
 				{},
 			},
-			err: "invalid content after value at 1:13",
+			err: "1:13: invalid content after value",
 		},
 	}
 
@@ -169,6 +170,32 @@ b ; foo`,
 	}
 }
 
+func TestInheritanceExpansion(t *testing.T) {
+	// Section inheriting from its parent would inherit its own pairs again.
+	doc := "[a]\nk = 1\n" + strings.Repeat("[a.b : a]\n", 20)
+	_, err := ReadAll(strings.NewReader(doc))
+	if err == nil || err.Error() != "3:1: section a.b can't inherit from its parent section a" {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Each level doubles number of pairs.
+	var b strings.Builder
+	b.WriteString("[t0]\nk = 1\n")
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&b, "[t%v]\n[t%v.a : t%v]\n[t%v.b : t%v]\n", i, i, i-1, i, i-1)
+	}
+	_, err = ReadAll(strings.NewReader(b.String()))
+	if err == nil || !strings.HasSuffix(err.Error(), "inherits too many properties (more than 65536)") {
+		t.Fatal("unexpected error", err)
+	}
+
+	// Repeated inheritance doesn't inherit pairs twice.
+	kvs, err := ReadAll(strings.NewReader("[a.b]\n[.b]\nk = 1\n[a : a.b]\n[a : a.b]\n[a : a.b]\n"))
+	if err != nil || len(kvs) != 4 {
+		t.Fatal("unexpected pairs", kvs, err)
+	}
+}
+
 func FuzzParser(f *testing.F) {
 	f.Add("[section]\nkey=value\n")
 	f.Add("[section]\nkey1=value1\nkey2=value2\n")
@@ -1,10 +1,14 @@
 package ini
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
 	"runtime"
 	"slices"
 	"strconv"
@@ -25,22 +29,97 @@ func NewPropSet(name string, errorHandling ErrorHandling) *PropSet {
 	return ps
 }
 
+const (
+	// DefaultMaxIncludeDepth is the maximum depth of nested include directives
+	// used when PropSet.MaxIncludeDepth is zero.
+	DefaultMaxIncludeDepth = 16
+
+	includeKey         = "include"
+	optionalIncludeKey = "include?"
+)
+
 // PropSet represents a set of defined options. The zero value of a PropSet
 // has no name and has ContinueOnError error handling.
 //
 // PropSet names must be unique within a PropSet. An attempt to define an
 // option whose name is already in use will cause a panic.
+//
+// Top-level "include = path" and "include? = path" directives parse the INI file
+// at path in place. Relative paths are resolved against the directory of the
+// including file. Unlike "include", "include?" ignores missing files.
+//
+// Properties of profile sections ("[@name section]") are only set if profile
+// is active (see PropSet.Profiles). They overlay other properties of the parsed document
+// regardless of their position.
+//
+// Properties of conditional sections ("[if fact=pattern,... section]") are
+// only set if all conditions hold (see PropSet.Evaluator). Unmatched sections
+// are still checked.
 type PropSet struct {
 	name          string
 	parsed        bool
 	formal        map[string]*Property
 	actual        map[string]*Property
+	provenance    map[string]Location
+	directives    map[string]func(string) error
 	undef         map[string]string
 	output        io.Writer
 	errorHandling ErrorHandling
 	Usage         func()
+	// MaxIncludeDepth is the maximum depth of nested include directives. If
+	// zero, DefaultMaxIncludeDepth is used.
+	MaxIncludeDepth int
+	// FS is the file system included files are read from. If nil, files are
+	// read from the host file system.
+	FS fs.FS
+	// OpenFile, if non-nil, is used to open included files and secret files
+	// from the host file system.
+	OpenFile func(name string) (io.ReadCloser, error)
+	// Evaluator evaluates conditions of conditional sections
+	// ("[if fact=pattern section]"). If nil, DefaultEvaluator is used.
+	Evaluator Evaluator
+	// Verify, if non-nil, is called with the name and content of each parsed
+	// document, including included files, before any of its properties is
+	// applied. Document is refused if it returns an error.
+	Verify func(name string, content []byte) error
+	// Properties whose value can be read from a file and properties that were,
+	// and properties set by key and key_file forms in parsed document.
+	secretFiles    map[string]bool
+	allSecretFiles bool
+	secrets        map[string]bool
+	commands       map[string]bool
+	keyForm        map[string]bool
+	fileForm       map[string]bool
+	// Profiles contains active profiles. Properties of a profile override
+	// those of profiles preceding it.
+	Profiles []string
+	// Profiles found in parsed documents and properties of active profiles
+	// pending for the end of current document.
+	profiles        []string
+	profileProperty []profileProperty
 }
 
+// profileProperty defines a property of an active profile section.
+type profileProperty struct {
+	prop         *Property
+	value        string
+	kind         valueKind
+	loc          Location
+	includedFrom []Location
+}
+
+// valueKind defines how the value of a property is read.
+type valueKind int
+
+const (
+	// literalValue is the value itself.
+	literalValue valueKind = iota
+	// fileValue is the path of a file containing the value.
+	fileValue
+	// commandValue is a command line whose output is the value.
+	commandValue
+)
+
 // Init sets the name and error handling property for a property set. By default,
 // the zero PropSet uses an empty name and the ContinueOnError error handling
 // policy.
@@ -69,17 +148,20 @@ func (ps *PropSet) Parse(r io.Reader) error {
 
 	ps.parsed = true
 
-	parser := newParser(r)
-
-	for {
-		seen, err := ps.parseOne(parser)
-		if seen {
-			continue
-		}
-		if err == nil {
-			break
-		}
-
+	ps.profileProperty = nil
+	ps.keyForm, ps.fileForm = nil, nil
+	name := ""
+	if named, ok := r.(interface{ Name() string }); ok {
+		name = named.Name()
+	}
+	r, err := ps.verify(r, name)
+	if err == nil {
+		err = ps.parseAll(ps.newParser(r))
+	}
+	if err == nil {
+		err = ps.applyProfiles()
+	}
+	if err != nil {
 		ps.usage()
 		switch ps.errorHandling {
 		case ContinueOnError:
@@ -94,6 +176,53 @@ func (ps *PropSet) Parse(r io.Reader) error {
 	return nil
 }
 
+// verify reads document r named name and verifies its content using
+// ps.Verify. It returns a reader of verified content with the same name.
+func (ps *PropSet) verify(r io.Reader, name string) (io.Reader, error) {
+	if ps.Verify == nil {
+		return r, nil
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	err = ps.Verify(name, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return namedReader{bytes.NewReader(content), name}, nil
+}
+
+// namedReader is an io.Reader with a name used as scanner file name.
+type namedReader struct {
+	io.Reader
+	name string
+}
+
+// Name implements interface{ Name() string }.
+func (nr namedReader) Name() string {
+	return nr.name
+}
+
+// newParser returns a new parser of r using property set evaluator.
+func (ps *PropSet) newParser(r io.Reader) *parser {
+	p := newParser(r)
+	p.eval = ps.Evaluator
+	return p
+}
+
+// parseAll parses all properties returned by parser.
+func (ps *PropSet) parseAll(parser *parser) error {
+	for {
+		seen, err := ps.parseOne(parser)
+		if !seen {
+			return err
+		}
+	}
+}
+
 func (ps *PropSet) parseOne(parser *parser) (bool, error) {
 	key, val, err := parser.parseNext()
 	if key == "" && val == "" && err == nil {
@@ -103,35 +232,422 @@ func (ps *PropSet) parseOne(parser *parser) (bool, error) {
 		return false, err
 	}
 
+	// Property of a conditional section whose conditions don't hold.
+	if parser.skip {
+		if key == includeKey || key == optionalIncludeKey || ps.directives[key] != nil {
+			return true, nil
+		}
+		if name, _, _ := ps.propertyKey(key, val); ps.formal[name] == nil {
+			return false, ps.fail(parser.errorf(parser.keyPos,
+				"property provided but not defined: %s", key))
+		}
+		return true, nil
+	}
+
+	// Profile property.
+	if parser.profile != "" {
+		return ps.parseProfileProperty(parser, key, val)
+	}
+
+	// Include directive.
+	if key == includeKey || key == optionalIncludeKey {
+		err := ps.include(parser, val, key == optionalIncludeKey)
+		return err == nil, err
+	}
+
+	// Custom directive.
+	if fn, ok := ps.directives[key]; ok {
+		err := fn(val)
+		if err != nil {
+			return false, ps.fail(parser.errorf(parser.valuePos,
+				"invalid value %q for directive %s: %w", val, key, err))
+		}
+		return true, nil
+	}
+
 	// Lookup property.
-	prop, ok := ps.formal[key]
+	name, val, kind := ps.propertyKey(key, val)
+	prop, ok := ps.formal[name]
 	if !ok {
-		return false, ps.failf("property provided but not defined: %s", key)
+		return false, ps.fail(parser.errorf(parser.keyPos,
+			"property provided but not defined: %s", key))
 	}
 
+	if ps.isSecretFile(name) {
+		if key == name+"_file" {
+			if ps.keyForm[name] {
+				return false, ps.fail(parser.errorf(parser.keyPos,
+					"properties %s and %s are both set", name, key))
+			}
+			if ps.fileForm == nil {
+				ps.fileForm = make(map[string]bool)
+			}
+			ps.fileForm[name] = true
+		} else {
+			if ps.fileForm[name] {
+				return false, ps.fail(parser.errorf(parser.keyPos,
+					"properties %s and %s_file are both set", name, name))
+			}
+			if ps.keyForm == nil {
+				ps.keyForm = make(map[string]bool)
+			}
+			ps.keyForm[name] = true
+		}
+	}
+
+	if kind == fileValue {
+		val = ps.resolvePath(parser, val)
+	}
+	err = ps.setValue(prop, val, kind, Location{
+		Filename: parser.scanner.Filename,
+		Pos:      parser.valuePos,
+	})
+	if err != nil {
+		return false, ps.fail(parser.errorf(parser.valuePos, "%w", err))
+	}
+
+	return true, nil
+}
+
+// propertyKey returns name, value and kind of value of property set by key
+// and val:
+//   - "name_file = path" and "name = @file:path" forms of properties whose
+//     value can be read from a file return path and fileValue
+//   - "name_cmd = command" and "name = !cmd command" forms of properties whose
+//     value can be read from the output of a command return command and
+//     commandValue
+//
+// Otherwise, key, val and literalValue are returned.
+func (ps *PropSet) propertyKey(key, val string) (string, string, valueKind) {
+	if ps.formal[key] == nil {
+		if name, ok := strings.CutSuffix(key, "_file"); ok && ps.isSecretFile(name) {
+			return name, val, fileValue
+		}
+		if name, ok := strings.CutSuffix(key, "_cmd"); ok && ps.isCommand(name) {
+			return name, val, commandValue
+		}
+	}
+
+	if ps.isSecretFile(key) {
+		if fpath, ok := strings.CutPrefix(val, "@file:"); ok {
+			return key, fpath, fileValue
+		}
+	}
+	if ps.isCommand(key) {
+		if cmdline, ok := strings.CutPrefix(val, option.CommandPrefix); ok {
+			return key, cmdline, commandValue
+		}
+	}
+
+	return key, val, literalValue
+}
+
+// setValue sets value of prop, parsed at loc, according to its kind.
+func (ps *PropSet) setValue(prop *Property, val string, kind valueKind, loc Location) error {
+	switch kind {
+	case fileValue:
+		return ps.setSecretFile(prop, val, loc)
+	case commandValue:
+		return ps.setCommand(prop, val, loc)
+	}
+
+	// Commands are refused rather than set as is if enabled for other
+	// properties.
+	if len(ps.commands) > 0 && strings.HasPrefix(val, option.CommandPrefix) {
+		return fmt.Errorf("commands aren't enabled for property %s", prop.Name)
+	}
+	return ps.setProperty(prop, val, loc)
+}
+
+// setProperty sets value of prop parsed at loc.
+func (ps *PropSet) setProperty(prop *Property, val string, loc Location) error {
 	if fv, ok := prop.Value.(interface{ IsBoolFlag() bool }); ok && fv.IsBoolFlag() {
 		if val != "" {
 			if err := prop.Value.Set(val); err != nil {
-				return false, ps.failf("invalid boolean value %q for %s: %v", val, key, err)
+				return fmt.Errorf("invalid boolean value %q for %s: %w", val, prop.Name, err)
 			}
 		}
+	} else if lv, ok := prop.Value.(option.LocatedValue); ok {
+		dir := ""
+		if loc.Filename != "" && ps.FS == nil {
+			dir = filepath.Dir(loc.Filename)
+		}
+		err := lv.SetLocated(val, dir, loc.position())
+		if err != nil {
+			return fmt.Errorf("invalid value %q for property %s: %w", val, prop.Name, err)
+		}
+	} else if rv, ok := prop.Value.(option.RelativeValue); ok && loc.Filename != "" && ps.FS == nil {
+		// Resolve relative paths against directory of file.
+		err := rv.SetRelative(val, filepath.Dir(loc.Filename))
+		if err != nil {
+			return fmt.Errorf("invalid value %q for property %s: %w", val, prop.Name, err)
+		}
 	} else {
 		// Set property.
 		err := prop.Value.Set(val)
 		if err != nil {
-			return false, ps.failf("invalid value %q for property %s: %v", val, key, err)
+			return fmt.Errorf("invalid value %q for property %s: %w", val, prop.Name, err)
 		}
 	}
 
-	// Mark property as defined.
+	ps.markSet(prop, loc, false)
+	return nil
+}
+
+// markSet marks prop as set at loc and whether its value is secret.
+func (ps *PropSet) markSet(prop *Property, loc Location, secret bool) {
 	if ps.actual == nil {
 		ps.actual = make(map[string]*Property)
 	}
-	ps.actual[key] = prop
+	ps.actual[prop.Name] = prop
+
+	if ps.provenance == nil {
+		ps.provenance = make(map[string]Location)
+	}
+	ps.provenance[prop.Name] = loc
+
+	if secret {
+		if ps.secrets == nil {
+			ps.secrets = make(map[string]bool)
+		}
+		ps.secrets[prop.Name] = true
+	}
+}
+
+// open opens file at fpath from FS, using OpenFile or from the host file
+// system.
+func (ps *PropSet) open(fpath string) (io.ReadCloser, error) {
+	if ps.FS != nil {
+		return ps.FS.Open(fpath)
+	}
+	if ps.OpenFile != nil {
+		return ps.OpenFile(fpath)
+	}
+	return os.Open(fpath)
+}
+
+// setSecretFile sets value of prop, parsed at loc, to content of file at
+// fpath.
+func (ps *PropSet) setSecretFile(prop *Property, fpath string, loc Location) error {
+	f, err := ps.open(fpath)
+	if err != nil {
+		return err
+	}
+	val, err := option.ReadSecret(f)
+	err = errors.Join(err, f.Close())
+	if err != nil {
+		return err
+	}
+
+	err = option.SetSecret(prop.Value, val)
+	if err != nil {
+		return fmt.Errorf("invalid value read from %s for property %s: %w", fpath, prop.Name, err)
+	}
+
+	ps.markSet(prop, loc, true)
+	return nil
+}
+
+// setCommand sets value of prop, parsed at loc, to the output of command line
+// cmdline.
+func (ps *PropSet) setCommand(prop *Property, cmdline string, loc Location) error {
+	err := option.SetCommand(prop.Value, cmdline)
+	if err != nil {
+		return fmt.Errorf("failed to read property %s from command: %w", prop.Name, err)
+	}
+
+	ps.markSet(prop, loc, true)
+	return nil
+}
+
+// SecretFiles enables reading value of named properties from files using
+// "name_file = path" or "name = @file:path" forms. File content is read
+// without trailing newline. Setting both name and name_file is an error. If
+// no name is provided, it is enabled for all properties.
+func (ps *PropSet) SecretFiles(names ...string) {
+	if len(names) == 0 {
+		ps.allSecretFiles = true
+		return
+	}
+
+	if ps.secretFiles == nil {
+		ps.secretFiles = make(map[string]bool)
+	}
+	for _, name := range names {
+		ps.secretFiles[name] = true
+	}
+}
+
+// isSecretFile reports whether value of defined property name can be read
+// from a file.
+func (ps *PropSet) isSecretFile(name string) bool {
+	_, defined := ps.formal[name]
+	return defined && (ps.allSecretFiles || ps.secretFiles[name])
+}
+
+// SecretCommands enables reading value of named properties from the output
+// of a command using "name_cmd = command" or "name = !cmd command" forms.
+// Commands are run by the property value, which must implement
+// [option.CommandValue], see [configue.Figue.SecretCommands]. Once enabled,
+// "!cmd" values of other properties are refused.
+func (ps *PropSet) SecretCommands(names ...string) {
+	if ps.commands == nil {
+		ps.commands = make(map[string]bool)
+	}
+	for _, name := range names {
+		ps.commands[name] = true
+	}
+}
+
+// isCommand reports whether value of defined property name can be read from
+// the output of a command.
+func (ps *PropSet) isCommand(name string) bool {
+	_, defined := ps.formal[name]
+	return defined && ps.commands[name]
+}
+
+// IsSecret reports whether value of named property was read from a secret
+// file or command.
+func (ps *PropSet) IsSecret(name string) bool {
+	return ps.secrets[name]
+}
+
+// parseProfileProperty records property key of a profile section. Properties
+// of active profiles are set by applyProfiles once document is parsed.
+func (ps *PropSet) parseProfileProperty(parser *parser, key, val string) (bool, error) {
+	profile := parser.profile
+	if !slices.Contains(ps.profiles, profile) {
+		ps.profiles = append(ps.profiles, profile)
+	}
+
+	if key == includeKey || key == optionalIncludeKey || ps.directives[key] != nil {
+		return false, ps.fail(parser.errorf(parser.keyPos,
+			"directive %s is not supported in profile section", key))
+	}
+
+	name, val, kind := ps.propertyKey(key, val)
+	prop, ok := ps.formal[name]
+	if !ok {
+		return false, ps.fail(parser.errorf(parser.keyPos,
+			"property provided but not defined: %s", key))
+	}
+	if kind == fileValue {
+		val = ps.resolvePath(parser, val)
+	}
+
+	if slices.Contains(ps.Profiles, profile) {
+		ps.profileProperty = append(ps.profileProperty, profileProperty{
+			prop:  prop,
+			value: val,
+			kind:  kind,
+			loc: Location{
+				Filename: parser.scanner.Filename,
+				Pos:      parser.valuePos,
+				Profile:  profile,
+			},
+			includedFrom: parser.includedFrom(),
+		})
+	}
 
 	return true, nil
 }
 
+// applyProfiles sets properties of active profiles in profiles order.
+func (ps *PropSet) applyProfiles() error {
+	for _, profile := range ps.Profiles {
+		for _, pp := range ps.profileProperty {
+			if pp.loc.Profile != profile {
+				continue
+			}
+
+			err := ps.setValue(pp.prop, pp.value, pp.kind, pp.loc)
+			if err != nil {
+				return ps.fail(&Error{
+					Filename:     pp.loc.Filename,
+					Pos:          pp.loc.Pos,
+					Err:          err,
+					IncludedFrom: pp.includedFrom,
+				})
+			}
+		}
+	}
+	ps.profileProperty = nil
+
+	return nil
+}
+
+// AvailableProfiles returns profiles found in parsed documents, in order of
+// appearance.
+func (ps *PropSet) AvailableProfiles() []string {
+	return slices.Clone(ps.profiles)
+}
+
+// Provenance returns location of the value that was last parsed for the named
+// property. It returns false if property wasn't set by Parse.
+func (ps *PropSet) Provenance(name string) (Location, bool) {
+	loc, ok := ps.provenance[name]
+	return loc, ok
+}
+
+// include parses INI file at fpath in place of the include directive parsed by
+// parser. Relative paths are resolved against the directory of including file.
+func (ps *PropSet) include(parser *parser, fpath string, optional bool) error {
+	fpath = ps.resolvePath(parser, fpath)
+
+	maxDepth := ps.MaxIncludeDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxIncludeDepth
+	}
+	if depth := len(parser.includedFrom()); depth >= maxDepth {
+		return ps.fail(parser.errorf(parser.valuePos,
+			"maximum include depth of %v exceeded", maxDepth))
+	}
+	if parser.includes(fpath) {
+		return ps.fail(parser.errorf(parser.valuePos, "include cycle on %v", fpath))
+	}
+
+	f, err := ps.open(fpath)
+	if err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return ps.fail(parser.errorf(parser.valuePos, "%w", err))
+	}
+
+	r, err := ps.verify(f, fpath)
+	if err != nil {
+		_ = f.Close()
+		return ps.fail(parser.errorf(parser.valuePos, "%w", err))
+	}
+
+	included := ps.newParser(r)
+	included.sections = parser.sections
+	included.scanner.Filename = fpath
+	included.parent = parser
+	return errors.Join(ps.parseAll(included), f.Close())
+}
+
+// resolvePath resolves relative path fpath against the directory of file
+// parsed by parser.
+func (ps *PropSet) resolvePath(parser *parser, fpath string) string {
+	if ps.FS != nil {
+		return path.Join(path.Dir(parser.scanner.Filename), fpath)
+	} else if !filepath.IsAbs(fpath) {
+		return filepath.Join(filepath.Dir(parser.scanner.Filename), fpath)
+	}
+	return fpath
+}
+
+// Directive registers fn as handler of key. Each time key is parsed, fn is
+// called with its value instead of setting a property. If fn returns a non-nil
+// error, it will be treated as a value parsing error.
+func (ps *PropSet) Directive(key string, fn func(value string) error) {
+	if ps.directives == nil {
+		ps.directives = make(map[string]func(string) error)
+	}
+	ps.directives[key] = fn
+}
+
 // Lookup returns the [Property] structure of the named property, returning nil
 // if none exists.
 func (ps *PropSet) Lookup(name string) *Property {
@@ -206,6 +722,7 @@ func (ps *PropSet) Set(key, value string) error {
 		ps.actual = make(map[string]*Property)
 	}
 	ps.actual[key] = prop
+	delete(ps.provenance, key)
 	return nil
 }
 
@@ -238,7 +755,16 @@ func (ps *PropSet) Output() io.Writer {
 //
 // The usage message appear as an INI comment on a separate line.
 func (ps *PropSet) PrintDefaults() {
+	_ = ps.WriteDefaults(ps.Output())
+}
+
+// WriteDefaults is like PrintDefaults but writes to w instead of the property
+// set output and returns the first write error. Its output is a valid INI
+// document setting properties to their default values. Default values are
+// escaped by values implementing [option.EscapedValue].
+func (ps *PropSet) WriteDefaults(w io.Writer) error {
 	var isZeroValueErrs []error
+	var err error
 	previousSection := ""
 
 	ps.VisitAll(func(prop *Property) {
@@ -253,7 +779,9 @@ func (ps *PropSet) PrintDefaults() {
 		// Section.
 		if section != previousSection {
 			_ = b.WriteByte('[')
-			if after, ok := strings.CutPrefix(section, previousSection); ok {
+			// Sections nested in previous one are written relative to it.
+			if after, ok := strings.CutPrefix(section, previousSection+"."); ok {
+				_ = b.WriteByte('.')
 				_, _ = b.WriteString(after)
 			} else {
 				_, _ = b.WriteString(section)
@@ -267,43 +795,53 @@ func (ps *PropSet) PrintDefaults() {
 
 		// Usage + default value.
 		name, usage := UnquoteUsage(prop.Value, prop.Usage)
-		_, _ = b.WriteString("; ")
+		if usage != "" {
+			for _, line := range strings.Split(usage, "\n") {
+				_, _ = b.WriteString("; ")
+				_, _ = b.WriteString(line)
+				_ = b.WriteByte('\n')
+			}
+		}
 		if len(name) > 0 {
-			_, _ = b.WriteString(usage)
-			_ = b.WriteByte('\n')
 			_, _ = b.WriteString("; ")
 			_, _ = b.WriteString(key)
 			_, _ = b.WriteString(" = ")
 			_, _ = b.WriteString(name)
-		} else {
-			_, _ = b.WriteString(prop.Usage)
+			_ = b.WriteByte('\n')
 		}
-		_ = b.WriteByte('\n')
 
 		_, _ = b.WriteString(padding)
 		_, _ = b.WriteString(key)
 		_, _ = b.WriteString(" = ")
-		if strings.ContainsAny(prop.DefValue, ";#\"`\\") ||
-			strings.TrimSpace(prop.DefValue) != prop.DefValue {
-			_, _ = b.WriteString(strconv.Quote(prop.DefValue))
+		defValue := option.Escape(prop.Value, prop.DefValue)
+		if strings.ContainsAny(defValue, ";#\"`\\\n") ||
+			strings.TrimSpace(defValue) != defValue {
+			_, _ = b.WriteString(strconv.Quote(defValue))
 		} else {
-			_, _ = b.WriteString(prop.DefValue)
+			_, _ = b.WriteString(defValue)
 		}
 		_, _ = b.WriteString("\n")
 
-		_, _ = fmt.Fprint(ps.Output(), b.String(), "\n")
+		if err == nil {
+			_, err = fmt.Fprint(w, b.String(), "\n")
+		}
 
 		previousSection = section
 	})
 	// If calling String on any zero option.Values triggered a panic, print
 	// the messages after the full set of defaults so that the programmer
 	// knows to fix the panic.
-	if errs := isZeroValueErrs; len(errs) > 0 {
-		_, _ = fmt.Fprintln(ps.Output())
-		for _, err := range errs {
-			_, _ = fmt.Fprintln(ps.Output(), err)
+	if errs := isZeroValueErrs; len(errs) > 0 && err == nil {
+		_, err = fmt.Fprintln(w)
+		for _, zeroErr := range errs {
+			if err != nil {
+				break
+			}
+			_, err = fmt.Fprintln(w, zeroErr)
 		}
 	}
+
+	return err
 }
 
 // VisitAll visits the options in lexicographical order, calling fn for each.
@@ -345,12 +883,12 @@ func (ps *PropSet) sprintf(format string, a ...any) string {
 	return msg
 }
 
-// failf prints to standard error a formatted error and usage message and
-// returns the error.
-func (ps *PropSet) failf(format string, a ...any) error {
-	msg := ps.sprintf(format, a...)
+// fail prints to standard error the given error and usage message and returns
+// it.
+func (ps *PropSet) fail(err error) error {
+	_, _ = fmt.Fprintln(ps.Output(), err)
 	ps.usage()
-	return errors.New(msg)
+	return err
 }
 
 func sortProperties(options map[string]*Property) []*Property {
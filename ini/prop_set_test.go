@@ -1,9 +1,12 @@
 package ini
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"math"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -128,6 +131,135 @@ uint64=101`))
 				})
 			})
 
+			t.Run("ErrorPosition", func(t *testing.T) {
+				t.Run("InvalidValue", func(t *testing.T) {
+					var ps PropSet
+					_ = ps.Int("db.port", 5432, "database port")
+
+					ps.SetOutput(io.Discard)
+					err := ps.Parse(namedReader{
+						strings.NewReader("[db]\n  port = abc ; comment"),
+						"config.ini",
+					})
+					expected := `config.ini:2:10: invalid value "abc" for property db.port: parse error`
+					if err == nil || err.Error() != expected {
+						t.Fatal("error doesn't match expected:", err)
+					}
+				})
+
+				t.Run("UndefinedProperty", func(t *testing.T) {
+					var ps PropSet
+
+					ps.SetOutput(io.Discard)
+					err := ps.Parse(strings.NewReader("\n\n [db]\n\tport = 5432"))
+					expected := "4:2: property provided but not defined: db.port"
+					if err == nil || err.Error() != expected {
+						t.Fatal("error doesn't match expected:", err)
+					}
+				})
+			})
+
+			t.Run("Include", func(t *testing.T) {
+				parseFile := func(ps *PropSet, fpath string) error {
+					f, err := os.Open(fpath)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					return ps.Parse(f)
+				}
+
+				t.Run("Success", func(t *testing.T) {
+					dir := writeFiles(t, map[string]string{
+						"main.ini":     "a = 1\ninclude = conf/a.ini\n[db]\nport = 3\n[]\ninclude? = missing.ini",
+						"conf/a.ini":   "a = 2\n[db]\nhost = localhost\nport = 2\n[]\ninclude = b/b.ini",
+						"conf/b/b.ini": "b = true",
+					})
+
+					var ps PropSet
+					a := ps.Int("a", 0, "")
+					b := ps.Bool("b", false, "")
+					host := ps.String("db.host", "", "")
+					port := ps.Int("db.port", 0, "")
+
+					err := parseFile(&ps, filepath.Join(dir, "main.ini"))
+					if err != nil {
+						t.Fatal("unexpected parse error", err)
+					}
+					if *a != 2 || !*b || *host != "localhost" || *port != 3 {
+						t.Fatal("unexpected value", *a, *b, *host, *port)
+					}
+				})
+
+				t.Run("Missing", func(t *testing.T) {
+					dir := writeFiles(t, map[string]string{
+						"main.ini": "include = missing.ini",
+					})
+
+					var ps PropSet
+					ps.SetOutput(io.Discard)
+					err := parseFile(&ps, filepath.Join(dir, "main.ini"))
+					if err == nil || !errors.Is(err, os.ErrNotExist) {
+						t.Fatal("error doesn't match expected:", err)
+					}
+				})
+
+				t.Run("ErrorInIncludedFile", func(t *testing.T) {
+					dir := writeFiles(t, map[string]string{
+						"main.ini": "include = a.ini",
+						"a.ini":    "\n\ninclude = b.ini",
+						"b.ini":    "\n\n\nport = abc",
+					})
+
+					var ps PropSet
+					_ = ps.Int("port", 0, "")
+					ps.SetOutput(io.Discard)
+					err := parseFile(&ps, filepath.Join(dir, "main.ini"))
+
+					expected := fmt.Sprintf(
+						`%[1]v/b.ini:4:8: invalid value "abc" for property port: parse error (included from %[1]v/a.ini:3, %[1]v/main.ini:1)`,
+						dir,
+					)
+					if err == nil || err.Error() != expected {
+						t.Fatal("error doesn't match expected:", err)
+					}
+				})
+
+				t.Run("Cycle", func(t *testing.T) {
+					dir := writeFiles(t, map[string]string{
+						"main.ini": "include = a.ini",
+						"a.ini":    "include = ./main.ini",
+					})
+
+					var ps PropSet
+					ps.SetOutput(io.Discard)
+					err := parseFile(&ps, filepath.Join(dir, "main.ini"))
+					if err == nil || !strings.Contains(err.Error(), "include cycle") {
+						t.Fatal("error doesn't match expected:", err)
+					}
+				})
+
+				t.Run("MaxDepth", func(t *testing.T) {
+					dir := writeFiles(t, map[string]string{
+						"main.ini": "include = a.ini",
+						"a.ini":    "include = b.ini",
+						"b.ini":    "",
+					})
+
+					var ps PropSet
+					ps.MaxIncludeDepth = 1
+					ps.SetOutput(io.Discard)
+					err := parseFile(&ps, filepath.Join(dir, "main.ini"))
+					expected := fmt.Sprintf(
+						"%[1]v/a.ini:1:11: maximum include depth of 1 exceeded (included from %[1]v/main.ini:1)",
+						dir,
+					)
+					if err == nil || err.Error() != expected {
+						t.Fatal("error doesn't match expected:", err)
+					}
+				})
+			})
+
 			t.Run("Name", func(t *testing.T) {
 				var ps PropSet
 				if ps.Name() != "" {
@@ -207,6 +339,51 @@ uint64 = 12345
 				}
 			})
 
+			t.Run("WriteDefaults", func(t *testing.T) {
+				newPropSet := func(defValue, usage string) (*PropSet, map[string]*string) {
+					var ps PropSet
+					values := make(map[string]*string)
+					for _, name := range []string{"a.b", "ab.c", "db.host", "db.sub.port", "dbx.name", "name"} {
+						values[name] = ps.String(name, defValue+name, usage)
+					}
+					return &ps, values
+				}
+
+				for _, tcase := range []struct {
+					defValue, usage string
+				}{
+					{"default ", ""},
+					{"default\n", "multi-line\nusage"},
+				} {
+					ps, _ := newPropSet(tcase.defValue, tcase.usage)
+					var b strings.Builder
+					err := ps.WriteDefaults(&b)
+					if err != nil {
+						t.Fatal("unexpected write error", err)
+					}
+					if strings.Contains(b.String(), "; \n") {
+						t.Fatal("unexpected empty usage comment", b.String())
+					}
+
+					ps, values := newPropSet("", "")
+					err = ps.Parse(strings.NewReader(b.String()))
+					if err != nil {
+						t.Fatal("unexpected parse error", err, b.String())
+					}
+					for name, value := range values {
+						if *value != tcase.defValue+name {
+							t.Fatalf("unexpected value for %v: %q\n%v", name, *value, b.String())
+						}
+					}
+				}
+
+				ps, _ := newPropSet("default ", "")
+				err := ps.WriteDefaults(failingWriter{})
+				if err == nil || err.Error() != "write failed" {
+					t.Fatal("unexpected write error", err)
+				}
+			})
+
 			t.Run("Lookup", func(t *testing.T) {
 				var ps PropSet
 
@@ -258,10 +435,451 @@ uint64 = 12345
 					}
 				})
 			})
+
+			t.Run("Conditions", func(t *testing.T) {
+				const doc = `[http]
+port = 8080
+
+[if host=web-*,os=linux http]
+port = 80
+
+[if host=db-* http]
+port = 5432
+workers = 1
+
+[if env:REGION=eu]
+[.http]
+host = eu.example.com
+`
+				newPropSet := func(facts map[string]string) (*PropSet, *int, *string) {
+					var ps PropSet
+					ps.SetOutput(io.Discard)
+					ps.Evaluator = NewEvaluator(facts)
+					port := ps.Int("http.port", 0, "")
+					host := ps.String("http.host", "", "")
+					_ = ps.Int("http.workers", 0, "")
+					return &ps, port, host
+				}
+
+				t.Run("Matched", func(t *testing.T) {
+					ps, port, host := newPropSet(map[string]string{
+						"host": "web-1", "os": "linux", "env:REGION": "eu",
+					})
+					err := ps.Parse(strings.NewReader(doc))
+					if err != nil {
+						t.Fatal("unexpected parse error", err)
+					}
+					if *port != 80 || *host != "eu.example.com" {
+						t.Fatal("unexpected values", *port, *host)
+					}
+				})
+
+				t.Run("Unmatched", func(t *testing.T) {
+					ps, port, host := newPropSet(map[string]string{
+						"host": "web-1", "os": "darwin",
+					})
+					t.Setenv("REGION", "us")
+					err := ps.Parse(strings.NewReader(doc))
+					if err != nil {
+						t.Fatal("unexpected parse error", err)
+					}
+					if *port != 8080 || *host != "" {
+						t.Fatal("unexpected values", *port, *host)
+					}
+				})
+
+				t.Run("Errors", func(t *testing.T) {
+					for _, tcase := range []struct {
+						doc, err string
+					}{
+						{"[if os=foo]\nbar = 1", "2:1: property provided but not defined: bar"},
+						{"[if os=foo]\nhttp.port = \"abc", "2:14: unclosed string"},
+						{"[if os]\n", `1:1: invalid condition "os"`},
+						{"[if os=[]\n", `1:1: invalid condition "os=[": syntax error in pattern`},
+						{"[if kernel=linux]\n", `1:1: unknown fact "kernel"`},
+					} {
+						ps, _, _ := newPropSet(nil)
+						err := ps.Parse(strings.NewReader(tcase.doc))
+						if err == nil || err.Error() != tcase.err {
+							t.Fatalf("unexpected error for %q: %v", tcase.doc, err)
+						}
+					}
+				})
+			})
+
+			t.Run("Inheritance", func(t *testing.T) {
+				newPropSet := func() (*PropSet, map[string]*string) {
+					var ps PropSet
+					ps.SetOutput(io.Discard)
+					values := make(map[string]*string)
+					for _, name := range []string{
+						"db.primary.host", "db.primary.port", "db.primary.tls.ca",
+						"db.replica.host", "db.replica.port", "db.replica.tls.ca",
+						"db.backup.host", "db.backup.port", "db.backup.tls.ca",
+					} {
+						values[name] = ps.String(name, "", "")
+					}
+					return &ps, values
+				}
+
+				t.Run("Success", func(t *testing.T) {
+					ps, values := newPropSet()
+					err := ps.Parse(strings.NewReader(`[db.primary]
+host = db1
+port = 5432
+[.tls]
+ca = ca.pem
+
+[db.replica : db.primary]
+host = db2
+
+[db.backup: db.replica]
+`))
+					if err != nil {
+						t.Fatal("unexpected parse error", err)
+					}
+					for name, expected := range map[string]string{
+						"db.primary.host": "db1", "db.primary.port": "5432", "db.primary.tls.ca": "ca.pem",
+						"db.replica.host": "db2", "db.replica.port": "5432", "db.replica.tls.ca": "ca.pem",
+						"db.backup.host": "db2", "db.backup.port": "5432", "db.backup.tls.ca": "ca.pem",
+					} {
+						if *values[name] != expected {
+							t.Fatalf("%v value %q doesn't match expected %q", name, *values[name], expected)
+						}
+					}
+				})
+
+				t.Run("Errors", func(t *testing.T) {
+					for _, tcase := range []struct {
+						doc, err string
+					}{
+						{"[db.replica : db.primary]\n[db.primary]\nhost = db1",
+							"1:1: section db.replica inherits from section db.primary which isn't defined before it"},
+						{"[db.primary : db.primary]\n", "1:1: section inheritance cycle: db.primary -> db.primary"},
+						{"[db.primary]\n[db.replica : db.primary]\n[db.primary : db.replica]\n",
+							"3:1: section inheritance cycle: db.primary -> db.replica -> db.primary"},
+						{"[ : db.primary]\n", "1:1: invalid section inheritance"},
+						{"[db.primary]\nhost = db1\n[db : db.primary]\n", "3:1: property provided but not defined: db.host"},
+					} {
+						ps, _ := newPropSet()
+						err := ps.Parse(strings.NewReader(tcase.doc))
+						if err == nil || err.Error() != tcase.err {
+							t.Fatalf("unexpected error for %q: %v", tcase.doc, err)
+						}
+					}
+				})
+			})
+
+			t.Run("SecretFiles", func(t *testing.T) {
+				dir := writeFiles(t, map[string]string{
+					"password":         "s3cr3t\r\n",
+					"port":             "8080\n",
+					"profile/password": "pr0f1le\n",
+				})
+				parse := func(ps *PropSet, doc string) error {
+					return ps.Parse(&namedReader{strings.NewReader(doc), filepath.Join(dir, "config.ini")})
+				}
+
+				t.Run("Success", func(t *testing.T) {
+					for _, doc := range []string{
+						"[db]\npassword_file = password\n",
+						"[db]\npassword = @file:password\n",
+						"[db]\npassword = @file:" + filepath.Join(dir, "password") + "\n",
+					} {
+						var ps PropSet
+						password := ps.String("db.password", "", "")
+						ps.SecretFiles("db.password")
+
+						err := parse(&ps, doc)
+						if err != nil {
+							t.Fatal("unexpected parse error", err)
+						}
+						if *password != "s3cr3t" || !ps.IsSecret("db.password") {
+							t.Fatalf("secret not read from file for %q: %q", doc, *password)
+						}
+					}
+				})
+
+				t.Run("Disabled", func(t *testing.T) {
+					var ps PropSet
+					ps.SetOutput(io.Discard)
+					password := ps.String("db.password", "", "")
+
+					err := parse(&ps, "[db]\npassword = @file:password\n")
+					if err != nil {
+						t.Fatal("unexpected parse error", err)
+					}
+					if *password != "@file:password" || ps.IsSecret("db.password") {
+						t.Fatal("secret read from file", *password)
+					}
+
+					err = parse(&ps, "[db]\npassword_file = password\n")
+					if err == nil || err.Error() != filepath.Join(dir, "config.ini")+":2:1: property provided but not defined: db.password_file" {
+						t.Fatal("unexpected error", err)
+					}
+				})
+
+				t.Run("Profiles", func(t *testing.T) {
+					for _, doc := range []string{
+						"[db]\npassword = base\n[@prod db]\npassword_file = profile/password\n",
+						"[db]\npassword = base\n[@prod db]\npassword = @file:profile/password\n",
+					} {
+						var ps PropSet
+						ps.Profiles = []string{"prod"}
+						password := ps.String("db.password", "", "")
+						ps.SecretFiles("db.password")
+
+						err := parse(&ps, doc)
+						if err != nil {
+							t.Fatalf("unexpected parse error for %q: %v", doc, err)
+						}
+						if *password != "pr0f1le" || !ps.IsSecret("db.password") {
+							t.Fatalf("secret not read from file for %q: %q", doc, *password)
+						}
+						if loc, _ := ps.Provenance("db.password"); loc.Profile != "prod" {
+							t.Fatalf("unexpected provenance for %q: %v", doc, loc)
+						}
+					}
+				})
+
+				t.Run("SkippedSections", func(t *testing.T) {
+					for _, doc := range []string{
+						"[db]\npassword = base\n[@prod db]\npassword_file = profile/password\n",
+						"[db]\npassword = base\n[if env:CONFIGUE_UNSET_VAR=* db]\npassword_file = profile/password\n",
+					} {
+						var ps PropSet
+						password := ps.String("db.password", "", "")
+						ps.SecretFiles("db.password")
+
+						err := parse(&ps, doc)
+						if err != nil {
+							t.Fatalf("unexpected parse error for %q: %v", doc, err)
+						}
+						if *password != "base" || ps.IsSecret("db.password") {
+							t.Fatalf("unexpected value for %q: %q", doc, *password)
+						}
+					}
+				})
+
+				t.Run("Errors", func(t *testing.T) {
+					for _, tcase := range []struct {
+						doc, err string
+					}{
+						{"port_file = password\n", `1:13: invalid value read from ` + filepath.Join(dir, "password") + ` for property port: parse error`},
+						{"port = 1\nport_file = port\n", "2:1: properties port and port_file are both set"},
+						{"port_file = port\nport = 1\n", "2:1: properties port and port_file are both set"},
+						{"port = @file:missing\n", "1:8: open " + filepath.Join(dir, "missing") + ": no such file or directory"},
+					} {
+						var ps PropSet
+						ps.SetOutput(io.Discard)
+						_ = ps.Int("port", 0, "")
+						ps.SecretFiles()
+
+						err := parse(&ps, tcase.doc)
+						if err == nil || err.Error() != filepath.Join(dir, "config.ini")+":"+tcase.err {
+							t.Fatalf("unexpected error for %q: %v", tcase.doc, err)
+						}
+					}
+				})
+			})
+
+			t.Run("SecretCommands", func(t *testing.T) {
+				for _, tcase := range []struct {
+					doc, expected string
+				}{
+					{"[db]\npassword_cmd = pass db\n", "cmd: pass db"},
+					{"[db]\npassword = !cmd pass db\n", "cmd: pass db"},
+					{"[db]\npassword = base\n[@prod db]\npassword_cmd = pass db/prod\n", "cmd: pass db/prod"},
+					{"[db]\npassword = base\n[@dev db]\npassword_cmd = pass db/dev\n", "base"},
+					{"[db]\npassword = base\n[if env:CONFIGUE_UNSET_VAR=* db]\npassword_cmd = pass db\n", "base"},
+				} {
+					var ps PropSet
+					ps.Profiles = []string{"prod"}
+					var password mockCommandVar
+					ps.Var(&password, "db.password", "")
+					ps.SecretCommands("db.password")
+
+					err := ps.Parse(strings.NewReader(tcase.doc))
+					if err != nil {
+						t.Fatalf("unexpected parse error for %q: %v", tcase.doc, err)
+					}
+					if string(password) != tcase.expected {
+						t.Fatalf("unexpected value for %q: %q", tcase.doc, password)
+					}
+					if ps.IsSecret("db.password") != (tcase.expected != "base") {
+						t.Fatalf("unexpected secret flag for %q", tcase.doc)
+					}
+				}
+
+				for _, tcase := range []struct {
+					doc, err string
+				}{
+					{"[db]\nuser = !cmd pass user\n", "2:8: commands aren't enabled for property db.user"},
+					{"[db]\nname_cmd = pass name\n", "2:12: failed to read property db.name from command: value can't be read from a command"},
+				} {
+					var ps PropSet
+					var password, user mockCommandVar
+					ps.Var(&password, "db.password", "")
+					ps.Var(&user, "db.user", "")
+					_ = ps.String("db.name", "", "")
+					ps.SecretCommands("db.password", "db.name")
+
+					err := ps.Parse(strings.NewReader(tcase.doc))
+					if err == nil || err.Error() != tcase.err {
+						t.Fatalf("unexpected error for %q: %v", tcase.doc, err)
+					}
+				}
+			})
+
+			t.Run("Profiles", func(t *testing.T) {
+				const doc = `[@prod db]
+host = prod.example.com
+port = 5433
+
+[db]
+host = localhost
+port = 5432
+user = admin
+
+[@dev db]
+host = dev.example.com
+
+[@local]
+[.db]
+host = 127.0.0.1
+`
+				newPropSet := func() (*PropSet, *string, *int, *string) {
+					var ps PropSet
+					ps.SetOutput(io.Discard)
+					host := ps.String("db.host", "", "")
+					port := ps.Int("db.port", 0, "")
+					user := ps.String("db.user", "", "")
+					return &ps, host, port, user
+				}
+
+				t.Run("Inactive", func(t *testing.T) {
+					ps, host, port, user := newPropSet()
+					err := ps.Parse(strings.NewReader(doc))
+					if err != nil {
+						t.Fatal("unexpected parse error", err)
+					}
+					if *host != "localhost" || *port != 5432 || *user != "admin" {
+						t.Fatal("unexpected values", *host, *port, *user)
+					}
+					profiles := ps.AvailableProfiles()
+					if strings.Join(profiles, ",") != "prod,dev,local" {
+						t.Fatal("unexpected available profiles", profiles)
+					}
+				})
+
+				t.Run("Active", func(t *testing.T) {
+					ps, host, port, user := newPropSet()
+					ps.Profiles = []string{"prod"}
+					err := ps.Parse(strings.NewReader(doc))
+					if err != nil {
+						t.Fatal("unexpected parse error", err)
+					}
+					if *host != "prod.example.com" || *port != 5433 || *user != "admin" {
+						t.Fatal("unexpected values", *host, *port, *user)
+					}
+
+					loc, ok := ps.Provenance("db.host")
+					if !ok || loc.String() != ":2 from profile prod" {
+						t.Fatal("unexpected provenance", loc)
+					}
+					loc, ok = ps.Provenance("db.user")
+					if !ok || loc.String() != ":8" {
+						t.Fatal("unexpected provenance", loc)
+					}
+				})
+
+				t.Run("Stacked", func(t *testing.T) {
+					ps, host, port, _ := newPropSet()
+					ps.Profiles = []string{"local", "prod"}
+					err := ps.Parse(strings.NewReader(doc))
+					if err != nil {
+						t.Fatal("unexpected parse error", err)
+					}
+					if *host != "prod.example.com" || *port != 5433 {
+						t.Fatal("unexpected values", *host, *port)
+					}
+
+					ps, host, port, _ = newPropSet()
+					ps.Profiles = []string{"prod", "local"}
+					err = ps.Parse(strings.NewReader(doc))
+					if err != nil {
+						t.Fatal("unexpected parse error", err)
+					}
+					if *host != "127.0.0.1" || *port != 5433 {
+						t.Fatal("unexpected values", *host, *port)
+					}
+				})
+
+				t.Run("ProfileNamespace", func(t *testing.T) {
+					// "profile." sections are ordinary sections.
+					var ps PropSet
+					url := ps.String("profile.avatar.url", "", "")
+					ps.Profiles = []string{"avatar"}
+					err := ps.Parse(strings.NewReader("[profile.avatar]\nurl = https://example.com/a.png\n"))
+					if err != nil {
+						t.Fatal("unexpected parse error", err)
+					}
+					if *url != "https://example.com/a.png" {
+						t.Fatal("unexpected url", *url)
+					}
+					if profiles := ps.AvailableProfiles(); len(profiles) != 0 {
+						t.Fatal("unexpected available profiles", profiles)
+					}
+				})
+
+				t.Run("Errors", func(t *testing.T) {
+					for _, tcase := range []struct {
+						doc, err string
+					}{
+						{"[@prod]\nfoo = 1", "2:1: property provided but not defined: foo"},
+						{"[@ db]\nport = 1", "1:1: invalid profile section"},
+						{"[@prod]\ninclude = a.ini", "2:1: directive include is not supported in profile section"},
+						{"[@prod db]\nport = abc", `2:8: invalid value "abc" for property db.port: parse error`},
+					} {
+						ps, _, _, _ := newPropSet()
+						ps.Profiles = []string{"prod"}
+						err := ps.Parse(strings.NewReader(tcase.doc))
+						if err == nil || err.Error() != tcase.err {
+							t.Fatalf("unexpected error for %q: %v", tcase.doc, err)
+						}
+					}
+				})
+			})
 		})
 	})
 }
 
+// mockCommandVar is a string value recording command lines it is read from.
+type mockCommandVar string
+
+// String implements option.Value.
+func (m *mockCommandVar) String() string { return string(*m) }
+
+// Set implements option.Value.
+func (m *mockCommandVar) Set(str string) error {
+	*m = mockCommandVar(str)
+	return nil
+}
+
+// SetCommand implements option.CommandValue.
+func (m *mockCommandVar) SetCommand(cmdline string) error {
+	*m = mockCommandVar("cmd: " + cmdline)
+	return nil
+}
+
+// failingWriter is an io.Writer always failing.
+type failingWriter struct{}
+
+// Write implements io.Writer.
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
 type mockTextVar struct {
 	str string
 	err error
@@ -284,3 +902,46 @@ func (m *mockTextVar) MarshalText() (text []byte, err error) {
 	}
 	return []byte(m.str), nil
 }
+
+func BenchmarkPropSetParse(b *testing.B) {
+	doc, sections := benchmarkDocument(4 << 20)
+
+	var ps PropSet
+	noop := func(string) error { return nil }
+	for i := range sections {
+		for _, key := range []string{"string", "quoted", "number", "multiline", "continued"} {
+			ps.Func(fmt.Sprintf("section%v.%v", i, key), "", noop)
+		}
+	}
+
+	b.SetBytes(int64(len(doc)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		if err := ps.Parse(strings.NewReader(doc)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// writeFiles writes files, whose names are relative to a new temporary
+// directory, and returns the directory. Parent directories are created as
+// needed.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		fpath := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(fpath), 0o700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(fpath, []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
@@ -0,0 +1,499 @@
+package ini
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TokenKind identifies the kind of a [Token].
+type TokenKind int
+
+const (
+	// BlankLine is an empty or whitespace only line.
+	BlankLine TokenKind = iota
+	// Comment is a comment starting with ';' or '#'. It either spans an entire
+	// line or trails a section header or a value.
+	Comment
+	// SectionHeader is a section header such as "[section]".
+	SectionHeader
+	// Key is the key of a key value pair.
+	Key
+	// Separator is the '=' or ':' separator between a key and its value.
+	Separator
+	// Value is the value of a key value pair. It may be quoted and span multiple
+	// lines.
+	Value
+)
+
+// String implements fmt.Stringer.
+func (k TokenKind) String() string {
+	switch k {
+	case BlankLine:
+		return "blank line"
+	case Comment:
+		return "comment"
+	case SectionHeader:
+		return "section header"
+	case Key:
+		return "key"
+	case Separator:
+		return "separator"
+	case Value:
+		return "value"
+	default:
+		return "TokenKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Position describes a location in an INI document.
+type Position struct {
+	Offset int // Byte offset, starting at 0.
+	Line   int // Line number, starting at 1.
+	Column int // Column number in bytes, starting at 1.
+}
+
+// String returns position formatted as "line:column".
+func (p Position) String() string {
+	return fmt.Sprintf("%v:%v", p.Line, p.Column)
+}
+
+// Token defines a lexical token of an INI document.
+type Token struct {
+	Kind TokenKind
+	// Pos is the position of the first byte of the token and End the position
+	// immediately after it.
+	Pos, End Position
+	// Raw is the source text of the token, including quotes and line
+	// terminators of multi-line values.
+	Raw string
+	// Text is the semantic content of the token: section name of a section
+	// header, key name, unquoted value. It is equal to Raw for other tokens.
+	Text string
+}
+
+// Location describes the location of an include directive or a value.
+type Location struct {
+	Filename string
+	Pos      Position
+	// Profile is the name of the profile section containing the value, if
+	// any.
+	Profile string
+}
+
+// String returns location formatted as "file:line", followed by
+// " from profile name" for values of profile sections.
+func (l Location) String() string {
+	if l.Profile != "" {
+		return fmt.Sprintf("%v:%v from profile %v", l.Filename, l.Pos.Line, l.Profile)
+	}
+	return fmt.Sprintf("%v:%v", l.Filename, l.Pos.Line)
+}
+
+// position returns location formatted as "file:line:column" or "line:column"
+// if file name is unknown.
+func (l Location) position() string {
+	if l.Filename != "" {
+		return l.Filename + ":" + l.Pos.String()
+	}
+	return l.Pos.String()
+}
+
+// Error describes an error located in an INI document. It is formatted as
+// "file:line:column: error" or "line:column: error" if file name is unknown.
+// Errors in included files are followed by the include chain, for example:
+// "a.ini:3:5: error (included from main.ini:1)".
+type Error struct {
+	Filename string
+	Pos      Position
+	Err      error
+	// IncludedFrom contains locations of include directives that led to
+	// Filename, innermost first.
+	IncludedFrom []Location
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.Filename != "" {
+		_, _ = b.WriteString(e.Filename)
+		_ = b.WriteByte(':')
+	}
+	_, _ = fmt.Fprintf(&b, "%v: %v", e.Pos, e.Err)
+
+	for i, loc := range e.IncludedFrom {
+		if i == 0 {
+			_, _ = b.WriteString(" (included from ")
+		} else {
+			_, _ = b.WriteString(", ")
+		}
+		_, _ = b.WriteString(loc.String())
+	}
+	if len(e.IncludedFrom) > 0 {
+		_ = b.WriteByte(')')
+	}
+
+	return b.String()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Scanner is a streaming tokenizer for the INI format used by [PropSet].
+// Successive calls to the Scan method step through the tokens of the document.
+// Scanning stops at the end of the input or on the first error. Unlike
+// bufio.Scanner, there is no limit on line length.
+type Scanner struct {
+	// Filename is the name of the scanned document used in errors.
+	Filename string
+
+	r      *bufio.Reader
+	buf    []byte // Buffer for lines that doesn't fit in r's buffer.
+	line   []byte // Current line without line terminator.
+	raw    []byte // Current line with line terminator.
+	lineNo int
+	offset int // Offset of current line.
+	next   int // Offset of next line.
+
+	// Buffers reused by multi-line values.
+	rawBuf, textBuf []byte
+
+	queue []Token
+	head  int
+	token Token
+	err   error
+}
+
+// NewScanner returns a new Scanner reading from r. If r has a Name method, such
+// as *os.File, it is used to initialize Filename.
+func NewScanner(r io.Reader) *Scanner {
+	s := &Scanner{r: bufio.NewReader(r)}
+	if named, ok := r.(interface{ Name() string }); ok {
+		s.Filename = named.Name()
+	}
+	return s
+}
+
+// Scan advances the Scanner to the next token, which will then be available
+// through the Token method. It returns false when scanning stops, either by
+// reaching the end of the input or an error. After Scan returns false, the Err
+// method will return any error that occurred during scanning, except that if
+// it was io.EOF, Err will return nil.
+func (s *Scanner) Scan() bool {
+	for s.head >= len(s.queue) {
+		s.queue, s.head = s.queue[:0], 0
+		if s.err != nil || !s.nextLine() {
+			return false
+		}
+		s.scanLine()
+	}
+
+	s.token = s.queue[s.head]
+	s.head++
+	return true
+}
+
+// Token returns the most recent token generated by a call to Scan.
+func (s *Scanner) Token() Token {
+	return s.token
+}
+
+// Err returns the first non-EOF error that was encountered by the Scanner.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+func (s *Scanner) nextLine() bool {
+	raw, err := s.readLine()
+	if err != nil && err != io.EOF {
+		s.err = err
+	}
+	if len(raw) == 0 || s.err != nil {
+		s.line, s.raw = nil, nil
+		s.offset = s.next
+		return false
+	}
+
+	s.raw = raw
+	s.line = raw
+	if n := len(s.line); n > 0 && s.line[n-1] == '\n' {
+		s.line = s.line[:n-1]
+	}
+	if n := len(s.line); n > 0 && s.line[n-1] == '\r' {
+		s.line = s.line[:n-1]
+	}
+	s.lineNo++
+	s.offset = s.next
+	s.next += len(s.raw)
+
+	return true
+}
+
+// readLine reads until the first '\n' or the end of input. Returned slice is
+// only valid until next read.
+func (s *Scanner) readLine() ([]byte, error) {
+	line, err := s.r.ReadSlice('\n')
+	if err != bufio.ErrBufferFull {
+		return line, err
+	}
+
+	// Line is longer than reader's buffer.
+	s.buf = append(s.buf[:0], line...)
+	for err == bufio.ErrBufferFull {
+		line, err = s.r.ReadSlice('\n')
+		s.buf = append(s.buf, line...)
+	}
+	return s.buf, err
+}
+
+// pos returns position of byte at index i of current line.
+func (s *Scanner) pos(i int) Position {
+	return Position{Offset: s.offset + i, Line: s.lineNo, Column: i + 1}
+}
+
+func (s *Scanner) emit(kind TokenKind, pos, end Position, raw, text string) {
+	s.queue = append(s.queue, Token{
+		Kind: kind,
+		Pos:  pos,
+		End:  end,
+		Raw:  raw,
+		Text: text,
+	})
+}
+
+// emitText emits a token whose text is its raw source.
+func (s *Scanner) emitText(kind TokenKind, start, end int) {
+	raw := string(s.line[start:end])
+	s.emit(kind, s.pos(start), s.pos(end), raw, raw)
+}
+
+func (s *Scanner) error(msg string, pos Position) {
+	s.err = &Error{Filename: s.Filename, Pos: pos, Err: errors.New(msg)}
+	s.queue, s.head = s.queue[:0], 0
+}
+
+// scanLine tokenizes current line and queues the tokens.
+func (s *Scanner) scanLine() {
+	start := len(s.line) - len(bytes.TrimLeftFunc(s.line, unicode.IsSpace))
+	end := len(bytes.TrimRightFunc(s.line, unicode.IsSpace))
+	if start >= end {
+		s.emitText(BlankLine, 0, len(s.line))
+		return
+	}
+
+	switch s.line[start] {
+	case ';', '#':
+		s.emitText(Comment, start, end)
+	case '[':
+		s.scanSection(start, end)
+	default:
+		s.scanKeyValue(start, end)
+	}
+}
+
+func (s *Scanner) scanSection(start, end int) {
+	contentEnd := end
+	if i := bytes.IndexAny(s.line[start:end], ";#"); i != -1 {
+		contentEnd = start + i
+	}
+
+	i := bytes.IndexByte(s.line[start+1:contentEnd], ']')
+	if i == -1 {
+		s.error("invalid section", s.pos(start+1))
+		return
+	}
+	closing := start + 1 + i
+
+	rest := bytes.TrimRightFunc(s.line[:contentEnd], unicode.IsSpace)
+	if after := skipSpace(rest, closing+1); after < len(rest) {
+		s.error("invalid content after section", s.pos(after))
+		return
+	}
+
+	name := s.line[start+1 : closing]
+	if len(name) > 0 && !isValidSection(name) {
+		s.error("invalid section", s.pos(start+1))
+		return
+	}
+
+	raw := string(s.line[start : closing+1])
+	s.emit(SectionHeader, s.pos(start), s.pos(closing+1), raw, raw[1:len(raw)-1])
+	if contentEnd < end {
+		s.emitText(Comment, contentEnd, end)
+	}
+}
+
+func (s *Scanner) scanKeyValue(start, end int) {
+	sep := bytes.IndexAny(s.line[start:end], "=:")
+	if sep == -1 {
+		s.error("invalid option, separators '=' or ':' are missing", s.pos(start))
+		return
+	}
+	sep += start
+
+	keyEnd := len(bytes.TrimRightFunc(s.line[:sep], unicode.IsSpace))
+	if keyEnd <= start {
+		s.error("invalid option, key is missing", s.pos(start))
+		return
+	}
+	s.emitText(Key, start, keyEnd)
+	if s.line[sep] == '=' {
+		s.emit(Separator, s.pos(sep), s.pos(sep+1), "=", "=")
+	} else {
+		s.emit(Separator, s.pos(sep), s.pos(sep+1), ":", ":")
+	}
+
+	valueStart := skipSpace(s.line[:end], sep+1)
+	if valueStart < end {
+		switch s.line[valueStart] {
+		case '"', '\'', '`':
+			s.scanQuotedValue(valueStart, end)
+			return
+		}
+	}
+	s.scanUnquotedValue(valueStart, end)
+}
+
+// scanUnquotedValue scans an unquoted value, ending with a comment or a line
+// terminator. Lines ending with a backslash are continued on the next line.
+func (s *Scanner) scanUnquotedValue(start, end int) {
+	pos := s.pos(start)
+	raw, text := s.rawBuf[:0], s.textBuf[:0]
+	defer func() { s.rawBuf, s.textBuf = raw, text }()
+
+	for {
+		contentEnd := end
+		if i := bytes.IndexAny(s.line[start:end], ";#"); i != -1 {
+			contentEnd = len(bytes.TrimRightFunc(s.line[:start+i], unicode.IsSpace))
+			contentEnd = max(contentEnd, start)
+		}
+		content := s.line[start:contentEnd]
+
+		if len(content) == 0 || content[len(content)-1] != '\\' {
+			if len(raw) == 0 {
+				// Single line value.
+				s.emitText(Value, start, contentEnd)
+			} else {
+				raw = append(raw, content...)
+				text = append(text, content...)
+				s.emit(Value, pos, s.pos(contentEnd), string(raw), string(text))
+			}
+			if contentEnd < end {
+				s.emitText(Comment, skipSpace(s.line[:end], contentEnd), end)
+			}
+			return
+		}
+
+		// Line continuation.
+		raw = append(raw, s.raw[start:]...)
+		text = append(text, content[:len(content)-1]...)
+		text = append(text, '\n')
+
+		if !s.nextLine() {
+			if s.err == nil {
+				s.emit(Value, pos, s.pos(0), string(raw), string(text))
+			}
+			return
+		}
+
+		start = len(s.line) - len(bytes.TrimLeftFunc(s.line, unicode.IsSpace))
+		end = len(bytes.TrimRightFunc(s.line, unicode.IsSpace))
+		raw = append(raw, s.line[:start]...)
+		end = max(start, end)
+	}
+}
+
+// scanQuotedValue scans a double quoted, single quoted or back quoted value.
+// Back quoted values may span multiple lines.
+func (s *Scanner) scanQuotedValue(start, end int) {
+	pos := s.pos(start)
+	quote := s.line[start]
+
+	raw := s.rawBuf[:0]
+	defer func() { s.rawBuf = raw }()
+	closing := indexQuote(s.line[start+1:], quote)
+	if closing == -1 {
+		if quote != '`' {
+			s.error("unclosed string", s.pos(start+1))
+			return
+		}
+
+		raw = append(raw, s.raw[start:]...)
+		for {
+			if !s.nextLine() {
+				if s.err == nil {
+					s.error("unclosed multiline string", s.pos(0))
+				}
+				return
+			}
+
+			closing = bytes.IndexByte(s.line, quote)
+			if closing != -1 {
+				break
+			}
+			raw = append(raw, s.raw...)
+		}
+		raw = append(raw, s.line[:closing+1]...)
+		end = len(bytes.TrimRightFunc(s.line, unicode.IsSpace))
+	} else {
+		closing += start + 1
+		raw = append(raw, s.line[start:closing+1]...)
+	}
+
+	rawStr := string(raw)
+	text, err := strconv.Unquote(rawStr)
+	if err != nil {
+		s.error("invalid string", pos)
+		return
+	}
+
+	after := skipSpace(s.line[:end], closing+1)
+	if after < end && s.line[after] != ';' && s.line[after] != '#' {
+		s.error("invalid content after value", s.pos(after))
+		return
+	}
+
+	s.emit(Value, pos, s.pos(closing+1), rawStr, text)
+	if after < end {
+		s.emitText(Comment, after, end)
+	}
+}
+
+// isValidSection reports whether section name contains at least one word
+// character (letter, digit or underscore).
+func isValidSection(name []byte) bool {
+	for _, b := range name {
+		if 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' ||
+			'0' <= b && b <= '9' || b == '_' {
+			return true
+		}
+	}
+	return false
+}
+
+// indexQuote returns index of first unescaped quote in buf or -1.
+func indexQuote(buf []byte, quote byte) int {
+	for i := 0; i < len(buf); i++ {
+		switch buf[i] {
+		case quote:
+			return i
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		}
+	}
+	return -1
+}
+
+// skipSpace returns index of first non space byte in buf starting at i.
+func skipSpace(buf []byte, i int) int {
+	return len(buf) - len(bytes.TrimLeftFunc(buf[min(i, len(buf)):], unicode.IsSpace))
+}
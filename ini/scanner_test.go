@@ -0,0 +1,220 @@
+package ini
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestScanner(t *testing.T) {
+	type testCase struct {
+		name   string
+		input  string
+		output []Token
+		err    string
+	}
+
+	pos := func(offset, line, col int) Position {
+		return Position{Offset: offset, Line: line, Column: col}
+	}
+
+	testCases := []testCase{
+		{
+			name:   "Empty",
+			input:  "",
+			output: []Token{},
+		},
+		{
+			name:  "BlankLines",
+			input: "\n  \t\r\n",
+			output: []Token{
+				{BlankLine, pos(0, 1, 1), pos(0, 1, 1), "", ""},
+				{BlankLine, pos(1, 2, 1), pos(4, 2, 4), "  \t", "  \t"},
+			},
+		},
+		{
+			name:  "Comments",
+			input: "; foo\n  # bar ",
+			output: []Token{
+				{Comment, pos(0, 1, 1), pos(5, 1, 6), "; foo", "; foo"},
+				{Comment, pos(8, 2, 3), pos(13, 2, 8), "# bar", "# bar"},
+			},
+		},
+		{
+			name:  "SectionHeader",
+			input: "[foo.bar] ; comment",
+			output: []Token{
+				{SectionHeader, pos(0, 1, 1), pos(9, 1, 10), "[foo.bar]", "foo.bar"},
+				{Comment, pos(10, 1, 11), pos(19, 1, 20), "; comment", "; comment"},
+			},
+		},
+		{
+			name:  "KeyValue",
+			input: "  foo = bar ;comment\r\nbaz:qux",
+			output: []Token{
+				{Key, pos(2, 1, 3), pos(5, 1, 6), "foo", "foo"},
+				{Separator, pos(6, 1, 7), pos(7, 1, 8), "=", "="},
+				{Value, pos(8, 1, 9), pos(11, 1, 12), "bar", "bar"},
+				{Comment, pos(12, 1, 13), pos(20, 1, 21), ";comment", ";comment"},
+				{Key, pos(22, 2, 1), pos(25, 2, 4), "baz", "baz"},
+				{Separator, pos(25, 2, 4), pos(26, 2, 5), ":", ":"},
+				{Value, pos(26, 2, 5), pos(29, 2, 8), "qux", "qux"},
+			},
+		},
+		{
+			name:  "EmptyValue",
+			input: "foo=",
+			output: []Token{
+				{Key, pos(0, 1, 1), pos(3, 1, 4), "foo", "foo"},
+				{Separator, pos(3, 1, 4), pos(4, 1, 5), "=", "="},
+				{Value, pos(4, 1, 5), pos(4, 1, 5), "", ""},
+			},
+		},
+		{
+			name:  "QuotedValue",
+			input: `foo = "a;b\"c" # comment`,
+			output: []Token{
+				{Key, pos(0, 1, 1), pos(3, 1, 4), "foo", "foo"},
+				{Separator, pos(4, 1, 5), pos(5, 1, 6), "=", "="},
+				{Value, pos(6, 1, 7), pos(14, 1, 15), `"a;b\"c"`, `a;b"c`},
+				{Comment, pos(15, 1, 16), pos(24, 1, 25), "# comment", "# comment"},
+			},
+		},
+		{
+			name:  "MultiLineUnquotedValue",
+			input: "foo = a \\\n  b ; comment",
+			output: []Token{
+				{Key, pos(0, 1, 1), pos(3, 1, 4), "foo", "foo"},
+				{Separator, pos(4, 1, 5), pos(5, 1, 6), "=", "="},
+				{Value, pos(6, 1, 7), pos(13, 2, 4), "a \\\n  b", "a \nb"},
+				{Comment, pos(14, 2, 5), pos(23, 2, 14), "; comment", "; comment"},
+			},
+		},
+		{
+			name:  "MultiLineBackQuotedValue",
+			input: "foo = `a\n;b\n`",
+			output: []Token{
+				{Key, pos(0, 1, 1), pos(3, 1, 4), "foo", "foo"},
+				{Separator, pos(4, 1, 5), pos(5, 1, 6), "=", "="},
+				{Value, pos(6, 1, 7), pos(13, 3, 2), "`a\n;b\n`", "a\n;b\n"},
+			},
+		},
+		{
+			name:  "MissingSeparator",
+			input: "\n  foo",
+			err:   "2:3: invalid option, separators '=' or ':' are missing",
+		},
+		{
+			name:  "MissingKey",
+			input: " = foo",
+			err:   "1:2: invalid option, key is missing",
+		},
+		{
+			name:  "UnclosedSection",
+			input: "[foo",
+			err:   "1:2: invalid section",
+		},
+		{
+			name:  "ContentAfterSection",
+			input: "[foo] bar",
+			err:   "1:7: invalid content after section",
+		},
+		{
+			name:  "UnclosedString",
+			input: `foo = "bar`,
+			err:   "1:8: unclosed string",
+		},
+		{
+			name:  "UnclosedMultiLineString",
+			input: "foo = `bar\nbaz",
+			err:   "2:1: unclosed multiline string",
+		},
+	}
+
+	for _, tcase := range testCases {
+		t.Run(tcase.name, func(t *testing.T) {
+			s := NewScanner(strings.NewReader(tcase.input))
+
+			var tokens []Token
+			for s.Scan() {
+				tokens = append(tokens, s.Token())
+			}
+
+			if tcase.err != "" {
+				if s.Err() == nil {
+					t.Fatalf("error nil doesn't match expected error %q", tcase.err)
+				}
+				if s.Err().Error() != tcase.err {
+					t.Fatalf("error %q doesn't match expected %q", s.Err(), tcase.err)
+				}
+				return
+			}
+			if s.Err() != nil {
+				t.Fatalf("unexpected error %q", s.Err())
+			}
+
+			if len(tokens) != len(tcase.output) {
+				t.Fatalf("tokens %+v doesn't match expected %+v", tokens, tcase.output)
+			}
+			for i, tok := range tokens {
+				if tok != tcase.output[i] {
+					t.Fatalf("token %+v doesn't match expected %+v", tok, tcase.output[i])
+				}
+			}
+		})
+	}
+}
+
+func TestScannerLongLine(t *testing.T) {
+	long := strings.Repeat("A", 1<<20)
+	input := "cert = " + long + "\nkey = `" + long + "\n" + long + "`\n"
+
+	s := NewScanner(strings.NewReader(input))
+	var values []string
+	for s.Scan() {
+		if tok := s.Token(); tok.Kind == Value {
+			values = append(values, tok.Text)
+		}
+	}
+	if s.Err() != nil {
+		t.Fatalf("unexpected error %q", s.Err())
+	}
+
+	if len(values) != 2 || values[0] != long || values[1] != long+"\n"+long {
+		t.Fatal("long values doesn't match expected")
+	}
+}
+
+// benchmarkDocument returns an INI document of approximately size bytes and its
+// number of sections.
+func benchmarkDocument(size int) (string, int) {
+	var (
+		b strings.Builder
+		i int
+	)
+	for i = 0; b.Len() < size; i++ {
+		fmt.Fprintf(&b, "; section number %v\n[section%v]\n", i, i)
+		fmt.Fprintf(&b, "string = foo bar baz ; comment\n")
+		fmt.Fprintf(&b, "quoted = \"foo\\tbar\"\n")
+		fmt.Fprintf(&b, "number: %v\n\n", i)
+		fmt.Fprintf(&b, "multiline = `foo\nbar\nbaz`\n")
+		fmt.Fprintf(&b, "continued = foo \\\n  bar\n\n")
+	}
+	return b.String(), i
+}
+
+func BenchmarkScanner(b *testing.B) {
+	doc, _ := benchmarkDocument(4 << 20)
+	b.SetBytes(int64(len(doc)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for range b.N {
+		s := NewScanner(strings.NewReader(doc))
+		for s.Scan() {
+		}
+		if s.Err() != nil {
+			b.Fatal(s.Err())
+		}
+	}
+}
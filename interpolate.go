@@ -0,0 +1,319 @@
+package configue
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/negrel/configue/option"
+)
+
+// interpolatedValue wraps values of options defined with [Figue.Var] so
+// references in raw strings provided by backends are interpolated before
+// setting wrapped value.
+type interpolatedValue struct {
+	option.Value
+	f    *Figue
+	path string
+}
+
+// Unwrap returns wrapped value.
+func (v *interpolatedValue) Unwrap() option.Value {
+	return v.Value
+}
+
+// IsBoolFlag forwards IsBoolFlag of wrapped value.
+func (v *interpolatedValue) IsBoolFlag() bool {
+	if bf, ok := v.Value.(interface{ IsBoolFlag() bool }); ok {
+		return bf.IsBoolFlag()
+	}
+	return false
+}
+
+// String implements option.Value.
+func (v *interpolatedValue) String() string {
+	if v == nil || v.Value == nil {
+		return ""
+	}
+	return v.Value.String()
+}
+
+// Set implements option.Value.
+func (v *interpolatedValue) Set(str string) error {
+	return v.f.set(v.path, str, "", "")
+}
+
+// SetRelative implements option.RelativeValue. dir is forwarded to wrapped
+// value if it implements option.RelativeValue.
+func (v *interpolatedValue) SetRelative(str, dir string) error {
+	return v.f.set(v.path, str, dir, "")
+}
+
+// SetLocated implements option.LocatedValue. loc prefixes errors of values
+// queued for interpolation.
+func (v *interpolatedValue) SetLocated(str, dir, loc string) error {
+	return v.f.set(v.path, str, dir, loc)
+}
+
+// SetSecret implements option.SecretValue. Secret content is never
+// interpolated.
+func (v *interpolatedValue) SetSecret(str string) error {
+	return v.f.setSecret(v.path, str)
+}
+
+// Escape implements option.EscapedValue. "$" is escaped unless interpolation
+// is disabled for the option.
+func (v *interpolatedValue) Escape(str string) string {
+	if v.f.literal[v.path] {
+		return str
+	}
+	return strings.ReplaceAll(str, "$", "$$")
+}
+
+// SetCommand implements option.CommandValue. Command output is never
+// interpolated.
+func (v *interpolatedValue) SetCommand(cmdline string) error {
+	return v.f.setCommand(v.path, cmdline)
+}
+
+// pendingValue defines a raw value queued for interpolation, the directory
+// relative paths are resolved against and the location of the value, if
+// known. Secret values are set as is.
+type pendingValue struct {
+	raw, dir, loc string
+	secret        bool
+}
+
+// errorf formats an error prefixed by location of pending value, if known.
+func (v pendingValue) errorf(format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	if v.loc != "" {
+		return fmt.Errorf("%v: %w", v.loc, err)
+	}
+	return err
+}
+
+// DisableInterpolation disables interpolation of values of options at paths:
+// "${...}" references and "$$" are set as is.
+func (f *Figue) DisableInterpolation(paths ...string) {
+	if f.literal == nil {
+		f.literal = make(map[string]bool)
+	}
+	for _, path := range paths {
+		f.literal[path] = true
+	}
+}
+
+// set interpolates and sets raw value of option at path. While backends are
+// parsed, values containing references are queued until all options are
+// collected so they can reference values parsed afterward. loc is the
+// location of raw, if known.
+func (f *Figue) set(path, raw, dir, loc string) error {
+	if f.decrypter != nil && option.IsEncrypted(raw) {
+		str, err := f.decrypter(raw)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt value of option %s: %w", path, err)
+		}
+		return f.setSecret(path, str)
+	}
+
+	if f.literal[path] {
+		return f.setValue(path, raw, dir)
+	}
+
+	if f.parsing && (strings.Contains(raw, "$") || len(f.pending[path]) > 0) {
+		if f.pending == nil {
+			f.pending = make(map[string][]pendingValue)
+		}
+		f.pending[path] = append(f.pending[path], pendingValue{raw: raw, dir: dir, loc: loc})
+		return nil
+	}
+
+	str, secret, err := f.interpolate(raw, []string{path})
+	if err != nil {
+		return err
+	}
+	return f.setInterpolated(path, str, dir, secret)
+}
+
+// setSecret marks option at path as secret and sets its value to str as is.
+func (f *Figue) setSecret(path, str string) error {
+	if f.secrets == nil {
+		f.secrets = make(map[string]bool)
+	}
+	f.secrets[path] = true
+
+	if len(f.pending[path]) > 0 {
+		f.pending[path] = append(f.pending[path], pendingValue{raw: str, secret: true})
+		return nil
+	}
+	return option.SetSecret(f.vars[path], str)
+}
+
+// setValue sets value of option at path. If dir isn't empty, it is used to
+// resolve relative values.
+func (f *Figue) setValue(path, str, dir string) error {
+	val := f.vars[path]
+	if rv, ok := val.(option.RelativeValue); ok && dir != "" {
+		return rv.SetRelative(str, dir)
+	}
+	return val.Set(str)
+}
+
+// setInterpolated sets interpolated value str of option at path. Environment
+// variables aren't expanded again by values implementing
+// option.ExpandedValue. Option is marked as secret if str contains the value
+// of a secret option.
+func (f *Figue) setInterpolated(path, str, dir string, secret bool) error {
+	if secret {
+		if f.secrets == nil {
+			f.secrets = make(map[string]bool)
+		}
+		f.secrets[path] = true
+	}
+	if ev, ok := f.vars[path].(option.ExpandedValue); ok {
+		return ev.SetExpanded(str, dir)
+	}
+	return f.setValue(path, str, dir)
+}
+
+// resolvePending interpolates and sets queued values of all options.
+func (f *Figue) resolvePending() error {
+	paths := make([]string, 0, len(f.pending))
+	for path := range f.pending {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		err := f.resolve(path, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolve interpolates and sets queued values of option at path. stack
+// contains paths of options being resolved.
+func (f *Figue) resolve(path string, stack []string) error {
+	values, ok := f.pending[path]
+	if !ok {
+		return nil
+	}
+	delete(f.pending, path)
+
+	stack = append(stack, path)
+	for _, pending := range values {
+		if pending.secret {
+			err := option.SetSecret(f.vars[path], pending.raw)
+			if err != nil {
+				return fmt.Errorf("invalid secret value for option %s: %w", path, err)
+			}
+			continue
+		}
+
+		raw := pending.raw
+		str, secret, err := f.interpolate(raw, stack)
+		if nested, ok := err.(nestedError); ok {
+			return nested.error
+		}
+		if err != nil {
+			return pending.errorf("failed to interpolate value %q of option %s: %w", raw, path, err)
+		}
+		err = f.setInterpolated(path, str, pending.dir, secret)
+		if err != nil {
+			// Don't leak values of referenced secret options.
+			if secret {
+				str = raw
+			}
+			return pending.errorf("invalid value %q for option %s: %w", str, path, err)
+		}
+	}
+
+	return nil
+}
+
+// nestedError wraps errors of referenced options resolution so they're
+// reported as is.
+type nestedError struct {
+	error
+}
+
+// interpolate replaces references in raw with their values. "$$" is replaced
+// by "$". secret is true if raw references a secret option.
+func (f *Figue) interpolate(raw string, stack []string) (str string, secret bool, err error) {
+	if !strings.Contains(raw, "$") {
+		return raw, false, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '$' || i+1 == len(raw) {
+			_ = b.WriteByte(raw[i])
+			continue
+		}
+
+		switch raw[i+1] {
+		case '$':
+			_ = b.WriteByte('$')
+			i++
+
+		case '{':
+			end := strings.IndexByte(raw[i+2:], '}')
+			if end == -1 {
+				return "", false, fmt.Errorf("unclosed reference at offset %v", i)
+			}
+			value, isSecret, err := f.lookupRef(raw[i+2:i+2+end], stack)
+			if err != nil {
+				return "", false, err
+			}
+			secret = secret || isSecret
+			_, _ = b.WriteString(value)
+			i += 2 + end
+
+		default:
+			_ = b.WriteByte(raw[i])
+		}
+	}
+
+	return b.String(), secret, nil
+}
+
+// lookupRef returns value of reference ref: "env:NAME" for environment
+// variable NAME, an option path or an environment variable name. ":-default"
+// suffix provides a default value used if referenced value is unset or empty.
+// secret is true if ref references a secret option.
+func (f *Figue) lookupRef(ref string, stack []string) (value string, secret bool, err error) {
+	name, def, hasDef := strings.Cut(ref, ":-")
+	if name == "" {
+		return "", false, fmt.Errorf("empty reference")
+	}
+
+	var ok bool
+	if envName, isEnv := strings.CutPrefix(name, "env:"); isEnv {
+		value, ok = os.LookupEnv(envName)
+	} else if val, defined := f.vars[name]; defined {
+		if slices.Contains(stack, name) {
+			cycle := append(slices.Clone(stack[slices.Index(stack, name):]), name)
+			return "", false, fmt.Errorf("interpolation cycle: %v", strings.Join(cycle, " -> "))
+		}
+		err := f.resolve(name, stack)
+		if err != nil {
+			return "", false, nestedError{err}
+		}
+		value, ok, secret = val.String(), true, f.secrets[name]
+	} else if strings.Contains(name, ".") {
+		return "", false, fmt.Errorf("reference to undefined option %s", name)
+	} else {
+		value, ok = os.LookupEnv(name)
+	}
+
+	if (!ok || value == "") && hasDef {
+		value = def
+	}
+
+	return value, secret, nil
+}
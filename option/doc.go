@@ -9,5 +9,9 @@ Boolean options may be:
 	1, 0, t, f, T, F, true, false, TRUE, FALSE, True, False
 
 Duration options accept any input valid for time.ParseDuration.
+
+Path options expand a leading "~" and environment variables. Relative paths are
+resolved against the directory of the configuration file that set them, or the
+current working directory.
 */
 package option
@@ -0,0 +1,102 @@
+package option
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AES-256-GCM encrypted values have the following form:
+//
+//	ENC[AES256_GCM,data:<base64 ciphertext and tag>,iv:<base64 nonce>]
+
+const (
+	encPrefix = "ENC["
+	encSuffix = "]"
+	encAESGCM = "AES256_GCM"
+)
+
+// IsEncrypted reports whether str is an encrypted value of the form
+// "ENC[...]".
+func IsEncrypted(str string) bool {
+	return strings.HasPrefix(str, encPrefix) && strings.HasSuffix(str, encSuffix)
+}
+
+// EncryptAESGCM encrypts plaintext with AES-256-GCM using 32 bytes key and
+// returns an "ENC[AES256_GCM,data:...,iv:...]" value.
+func EncryptAESGCM(key []byte, plaintext string) (string, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, aead.NonceSize())
+	_, err = rand.Read(iv)
+	if err != nil {
+		return "", err
+	}
+	data := aead.Seal(nil, iv, []byte(plaintext), nil)
+
+	return fmt.Sprintf("%s%s,data:%s,iv:%s%s", encPrefix, encAESGCM,
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv), encSuffix), nil
+}
+
+// DecryptAESGCM decrypts value returned by EncryptAESGCM using 32 bytes key.
+// Returned errors never contain the plaintext.
+func DecryptAESGCM(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("value isn't encrypted")
+	}
+	fields := strings.Split(value[len(encPrefix):len(value)-len(encSuffix)], ",")
+	if fields[0] != encAESGCM {
+		return "", fmt.Errorf("unsupported encryption %q", fields[0])
+	}
+
+	var data, iv []byte
+	for _, field := range fields[1:] {
+		name, b64, _ := strings.Cut(field, ":")
+		decoded, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			return "", fmt.Errorf("invalid %v field of encrypted value: %w", name, err)
+		}
+		switch name {
+		case "data":
+			data = decoded
+		case "iv":
+			iv = decoded
+		default:
+			return "", fmt.Errorf("unknown field %q in encrypted value", name)
+		}
+	}
+
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(iv) != aead.NonceSize() {
+		return "", fmt.Errorf("invalid iv size of encrypted value: %v", len(iv))
+	}
+
+	plaintext, err := aead.Open(nil, iv, data, nil)
+	if err != nil {
+		return "", errors.New("message authentication failed, wrong key or corrupted value")
+	}
+	return string(plaintext), nil
+}
+
+// newAESGCM returns an AES-256-GCM cipher using key.
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid AES-256 key size: %v bytes, expected 32", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
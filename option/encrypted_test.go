@@ -0,0 +1,47 @@
+package option
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAESGCM(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	encrypted, err := EncryptAESGCM(key, "p4ssw0rd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(encrypted) || !strings.HasPrefix(encrypted, "ENC[AES256_GCM,data:") {
+		t.Fatal("unexpected encrypted value", encrypted)
+	}
+
+	plaintext, err := DecryptAESGCM(key, encrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext != "p4ssw0rd" {
+		t.Fatal("unexpected plaintext", plaintext)
+	}
+
+	t.Run("WrongKey", func(t *testing.T) {
+		_, err := DecryptAESGCM([]byte("fedcba9876543210fedcba9876543210"), encrypted)
+		if err == nil || strings.Contains(err.Error(), "p4ssw0rd") {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("InvalidKeySize", func(t *testing.T) {
+		_, err := EncryptAESGCM(key[:16], "p4ssw0rd")
+		if err == nil || err.Error() != "invalid AES-256 key size: 16 bytes, expected 32" {
+			t.Fatal("unexpected error", err)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		_, err := DecryptAESGCM(key, "ENC[PGP,data:AAAA]")
+		if err == nil || err.Error() != `unsupported encryption "PGP"` {
+			t.Fatal("unexpected error", err)
+		}
+	})
+}
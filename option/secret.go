@@ -0,0 +1,79 @@
+package option
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// SecretValue is implemented by values that handle secret content, such as a
+// password read from a file, differently: secret content must be set as is and
+// never printed.
+type SecretValue interface {
+	Value
+	SetSecret(str string) error
+}
+
+// SetSecret sets secret content str to val using SetSecret if val implements
+// SecretValue or Set otherwise.
+func SetSecret(val Value, str string) error {
+	if sv, ok := val.(SecretValue); ok {
+		return sv.SetSecret(str)
+	}
+	return val.Set(str)
+}
+
+// CommandPrefix is the prefix of values read from the output of a command:
+// "!cmd name args...".
+const CommandPrefix = "!cmd "
+
+// CommandValue is implemented by values that can be read from the output of a
+// command. Only trusted local sources, such as configuration files and
+// environment variables, call SetCommand with command line of values enabled
+// for it.
+type CommandValue interface {
+	Value
+	SetCommand(cmdline string) error
+}
+
+// SetCommand sets val to the output of command line cmdline using SetCommand
+// if val implements CommandValue. Otherwise, an error is returned.
+func SetCommand(val Value, cmdline string) error {
+	if cv, ok := val.(CommandValue); ok {
+		return cv.SetCommand(cmdline)
+	}
+	return errors.New("value can't be read from a command")
+}
+
+// ReadSecretFile reads secret file at path from fsys, or the host file system
+// if fsys is nil, and returns its content without trailing newline.
+func ReadSecretFile(fsys fs.FS, path string) (string, error) {
+	var (
+		f   io.ReadCloser
+		err error
+	)
+	if fsys != nil {
+		f, err = fsys.Open(path)
+	} else {
+		f, err = os.Open(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	return ReadSecret(f)
+}
+
+// ReadSecret reads secret from r and returns it without trailing newline.
+func ReadSecret(r io.Reader) (string, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	str := strings.TrimSuffix(string(content), "\n")
+	return strings.TrimSuffix(str, "\r"), nil
+}
@@ -29,6 +29,18 @@ type Value = flag.Value
 // this package satisfy the Getter interface, except the type used by Func.
 type Getter = flag.Getter
 
+// Unwrap returns the Value wrapped by val if it implements an
+// Unwrap() Value method, recursively. Otherwise, val is returned.
+func Unwrap(val Value) Value {
+	for {
+		u, ok := val.(interface{ Unwrap() Value })
+		if !ok {
+			return val
+		}
+		val = u.Unwrap()
+	}
+}
+
 type Bool bool
 
 // NewBool creates a new boolean value.
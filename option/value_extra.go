@@ -3,6 +3,9 @@ package option
 import (
 	"encoding"
 	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
 	"time"
@@ -84,6 +87,15 @@ func (s *Slice[T]) Set(str string) error {
 	return nil
 }
 
+// SetDefault is like Set but next call to Set will replace slice content
+// instead of appending to it.
+func (s *Slice[T]) SetDefault(str string) error {
+	s.isDefined = false
+	err := s.Set(str)
+	s.isDefined = false
+	return err
+}
+
 // String implements Value.
 func (s *Slice[T]) String() string {
 	if s == nil || s.data == nil || *s.data == nil {
@@ -134,3 +146,169 @@ func (s *Slice[T]) String() string {
 
 	return b.String()
 }
+
+// RelativeValue is implemented by values resolving relative paths. Sources
+// such as configuration files call SetRelative with their directory instead
+// of Set so relative paths are resolved against it.
+type RelativeValue interface {
+	Value
+	SetRelative(str, dir string) error
+}
+
+// ExpandedValue is implemented by values expanding environment variables.
+// Sources that already expanded references of str, such as interpolation,
+// call SetExpanded instead of SetRelative so they aren't expanded twice.
+type ExpandedValue interface {
+	Value
+	SetExpanded(str, dir string) error
+}
+
+// LocatedValue is implemented by values that may be validated after their
+// source is parsed. Sources call SetLocated with the location of str,
+// formatted as "file:line:column", instead of SetRelative so later errors can
+// refer to it.
+type LocatedValue interface {
+	Value
+	SetLocated(str, dir, loc string) error
+}
+
+// EscapedValue is implemented by values giving a special meaning to some
+// characters of str, such as interpolation references. Sources writing
+// default values, such as INI files created on first run, call Escape so they
+// are read back unchanged.
+type EscapedValue interface {
+	Value
+	Escape(str string) string
+}
+
+// Escape returns str escaped by val if it implements EscapedValue and str
+// otherwise.
+func Escape(val Value, str string) string {
+	if ev, ok := val.(EscapedValue); ok {
+		return ev.Escape(str)
+	}
+	return str
+}
+
+// PathCheck defines checks performed by Path each time it is set. Checks can
+// be combined using bitwise OR.
+type PathCheck int
+
+const (
+	// PathExists checks that path exists.
+	PathExists PathCheck = 1 << iota
+	// PathIsFile checks that path exists and isn't a directory.
+	PathIsFile
+	// PathIsDir checks that path exists and is a directory.
+	PathIsDir
+	// PathReadable checks that path exists and can be opened for reading.
+	PathReadable
+)
+
+// NewPath creates a new file system path value. "~" and environment variables
+// of val are expanded.
+func NewPath(val string, p *string, checks ...PathCheck) *Path {
+	*p = expandPath(val)
+	path := &Path{p: p}
+	for _, check := range checks {
+		path.checks |= check
+	}
+	return path
+}
+
+// Path is a file system path Value. On Set, "~" and environment variables are
+// expanded and relative paths are resolved against the current working
+// directory, or the directory provided to SetRelative. Checks are then
+// performed.
+type Path struct {
+	p      *string
+	checks PathCheck
+}
+
+// Set implements Value.
+func (p *Path) Set(str string) error {
+	return p.SetRelative(str, "")
+}
+
+// SetRelative implements RelativeValue.
+func (p *Path) SetRelative(str, dir string) error {
+	return p.SetExpanded(os.ExpandEnv(str), dir)
+}
+
+// SetExpanded implements ExpandedValue. Only "~" of str is expanded.
+func (p *Path) SetExpanded(str, dir string) error {
+	path := expandHome(str)
+	if path != "" && !filepath.IsAbs(path) {
+		if dir == "" {
+			var err error
+			dir, err = os.Getwd()
+			if err != nil {
+				return err
+			}
+		}
+		path = filepath.Join(dir, path)
+	}
+
+	if path != "" {
+		err := p.checks.check(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	*p.p = path
+	return nil
+}
+
+// Get implements Getter.
+func (p *Path) Get() any { return *p.p }
+
+// String implements Value.
+func (p *Path) String() string {
+	if p == nil || p.p == nil {
+		return ""
+	}
+	return *p.p
+}
+
+// check performs checks on path.
+func (c PathCheck) check(path string) error {
+	if c == 0 {
+		return nil
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if c&PathIsFile != 0 && stat.IsDir() {
+		return fmt.Errorf("%v is a directory", path)
+	}
+	if c&PathIsDir != 0 && !stat.IsDir() {
+		return fmt.Errorf("%v is not a directory", path)
+	}
+	if c&PathReadable != 0 {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		_ = f.Close()
+	}
+
+	return nil
+}
+
+// expandPath expands leading "~" and environment variables of path.
+func expandPath(path string) string {
+	return expandHome(os.ExpandEnv(path))
+}
+
+// expandHome expands leading "~" of path.
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = home + path[1:]
+		}
+	}
+	return path
+}
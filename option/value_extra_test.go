@@ -3,7 +3,10 @@ package option
 import (
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 	"unsafe"
 )
@@ -74,3 +77,88 @@ func TestSlice(t *testing.T) {
 		})
 	})
 }
+
+func TestPath(t *testing.T) {
+	t.Setenv("HOME", "/home/user")
+	t.Setenv("APP", "myapp")
+
+	t.Run("Default", func(t *testing.T) {
+		var p string
+		_ = NewPath("~/.${APP}", &p)
+		if p != "/home/user/.myapp" {
+			t.Fatal("unexpected default path", p)
+		}
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, tcase := range []struct {
+			str, dir, expected string
+		}{
+			{"~", "", "/home/user"},
+			{"~/.config/$APP", "", "/home/user/.config/myapp"},
+			{"/etc/myapp", "/ignored", "/etc/myapp"},
+			{"certs/server.pem", "", filepath.Join(cwd, "certs/server.pem")},
+			{"certs/server.pem", "/etc/myapp", "/etc/myapp/certs/server.pem"},
+			{"../data", "/etc/myapp", "/etc/data"},
+			{"", "/etc/myapp", ""},
+		} {
+			var p string
+			err := NewPath("", &p).SetRelative(tcase.str, tcase.dir)
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if p != tcase.expected {
+				t.Fatalf("path %q resolved to %q instead of %q", tcase.str, p, tcase.expected)
+			}
+		}
+	})
+
+	t.Run("SetExpanded", func(t *testing.T) {
+		var p string
+		err := NewPath("", &p).SetExpanded("~/$APP", "/etc")
+		if err != nil {
+			t.Fatal("unexpected error", err)
+		}
+		if p != "/home/user/$APP" {
+			t.Fatal("unexpected path", p)
+		}
+	})
+
+	t.Run("Checks", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "file")
+		err := os.WriteFile(file, nil, 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		missing := filepath.Join(dir, "missing")
+
+		for _, tcase := range []struct {
+			path  string
+			check PathCheck
+			err   string
+		}{
+			{missing, 0, ""},
+			{missing, PathExists, "no such file or directory"},
+			{file, PathExists, ""},
+			{file, PathIsFile | PathReadable, ""},
+			{dir, PathIsFile, "is a directory"},
+			{dir, PathIsDir | PathReadable, ""},
+			{file, PathIsDir, "is not a directory"},
+		} {
+			var p string
+			err := NewPath("", &p, tcase.check).Set(tcase.path)
+			if tcase.err == "" && err != nil {
+				t.Fatalf("unexpected error for %q: %v", tcase.path, err)
+			}
+			if tcase.err != "" && (err == nil || !strings.Contains(err.Error(), tcase.err)) {
+				t.Fatalf("expected error %q for %q, got %v", tcase.err, tcase.path, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package configue
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+)
+
+// Paths resolves standard directories of an application following the XDG
+// Base Directory Specification. Unlike [AppDir] and [File], application name is
+// explicit and doesn't depend on os.Args[0].
+type Paths struct {
+	App string
+}
+
+// NewPaths returns a new Paths resolver for the given application name.
+func NewPaths(app string) Paths {
+	return Paths{App: app}
+}
+
+// ConfigDir returns user configuration directory of the application:
+// $XDG_CONFIG_HOME/app if set, otherwise os.UserConfigDir()/app.
+func (p Paths) ConfigDir() (string, error) {
+	return p.userDir("XDG_CONFIG_HOME", os.UserConfigDir)
+}
+
+// ConfigDirs returns system configuration directories of the application,
+// ordered from most to least important: $XDG_CONFIG_DIRS entries (/etc/xdg by
+// default) joined with application name. On Unix systems, /etc/app is
+// appended.
+func (p Paths) ConfigDirs() []string {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		return nil
+	}
+
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv("XDG_CONFIG_DIRS")) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, filepath.Join(dir, p.App))
+		}
+	}
+	if len(dirs) == 0 {
+		dirs = append(dirs, filepath.Join("/etc/xdg", p.App))
+	}
+
+	return append(dirs, filepath.Join("/etc", p.App))
+}
+
+// CacheDir returns user cache directory of the application: $XDG_CACHE_HOME/app
+// if set, otherwise os.UserCacheDir()/app.
+func (p Paths) CacheDir() (string, error) {
+	return p.userDir("XDG_CACHE_HOME", os.UserCacheDir)
+}
+
+// StateDir returns user state directory of the application:
+// $XDG_STATE_HOME/app if set, otherwise $HOME/.local/state/app. On Windows,
+// os.UserCacheDir()/app is used instead.
+func (p Paths) StateDir() (string, error) {
+	return p.userDir("XDG_STATE_HOME", func() (string, error) {
+		if runtime.GOOS == "windows" {
+			return os.UserCacheDir()
+		}
+		return homeDir(".local", "state")
+	})
+}
+
+// DataDir returns user data directory of the application: $XDG_DATA_HOME/app
+// if set, otherwise $HOME/.local/share/app. On Windows, os.UserConfigDir()/app
+// is used instead.
+func (p Paths) DataDir() (string, error) {
+	return p.userDir("XDG_DATA_HOME", func() (string, error) {
+		if runtime.GOOS == "windows" {
+			return os.UserConfigDir()
+		}
+		return homeDir(".local", "share")
+	})
+}
+
+// ConfigFiles returns every candidate location of configuration file fname,
+// ordered from lowest to highest priority: system configuration directories
+// then user configuration directory. Returned slice can be passed as is to
+// [NewINIFiles].
+func (p Paths) ConfigFiles(fname string) []string {
+	var files []string
+	for _, dir := range slices.Backward(p.ConfigDirs()) {
+		files = append(files, filepath.Join(dir, fname))
+	}
+	if dir, err := p.ConfigDir(); err == nil {
+		files = append(files, filepath.Join(dir, fname))
+	}
+	return files
+}
+
+// userDir returns directory stored in env var envName joined with application
+// name. If env var is unset or isn't an absolute path, fallback is used.
+func (p Paths) userDir(envName string, fallback func() (string, error)) (string, error) {
+	dir := os.Getenv(envName)
+	if !filepath.IsAbs(dir) {
+		var err error
+		dir, err = fallback()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return filepath.Join(dir, p.App), nil
+}
+
+// homeDir returns user home directory joined with elem.
+func homeDir(elem ...string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{home}, elem...)...), nil
+}
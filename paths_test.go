@@ -0,0 +1,90 @@
+package configue
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestPaths(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("XDG paths are only tested on linux")
+	}
+
+	paths := NewPaths("myapp")
+
+	t.Run("XDG", func(t *testing.T) {
+		t.Setenv("HOME", "/home/user")
+		t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+		t.Setenv("XDG_CONFIG_DIRS", "/xdg/dir1:relative:/xdg/dir2")
+		t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+		t.Setenv("XDG_STATE_HOME", "/xdg/state")
+		t.Setenv("XDG_DATA_HOME", "/xdg/data")
+
+		for _, tcase := range []struct {
+			fn       func() (string, error)
+			expected string
+		}{
+			{paths.ConfigDir, "/xdg/config/myapp"},
+			{paths.CacheDir, "/xdg/cache/myapp"},
+			{paths.StateDir, "/xdg/state/myapp"},
+			{paths.DataDir, "/xdg/data/myapp"},
+		} {
+			dir, err := tcase.fn()
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if dir != tcase.expected {
+				t.Fatalf("dir %q doesn't match expected %q", dir, tcase.expected)
+			}
+		}
+
+		files := paths.ConfigFiles("config.ini")
+		expected := []string{
+			"/etc/myapp/config.ini",
+			"/xdg/dir2/myapp/config.ini",
+			"/xdg/dir1/myapp/config.ini",
+			"/xdg/config/myapp/config.ini",
+		}
+		if !slices.Equal(files, expected) {
+			t.Fatalf("files %q doesn't match expected %q", files, expected)
+		}
+	})
+
+	t.Run("Defaults", func(t *testing.T) {
+		t.Setenv("HOME", "/home/user")
+		t.Setenv("XDG_CONFIG_HOME", "")
+		t.Setenv("XDG_CONFIG_DIRS", "")
+		t.Setenv("XDG_CACHE_HOME", "")
+		t.Setenv("XDG_STATE_HOME", "relative")
+		t.Setenv("XDG_DATA_HOME", "")
+
+		for _, tcase := range []struct {
+			fn       func() (string, error)
+			expected string
+		}{
+			{paths.ConfigDir, "/home/user/.config/myapp"},
+			{paths.CacheDir, "/home/user/.cache/myapp"},
+			{paths.StateDir, "/home/user/.local/state/myapp"},
+			{paths.DataDir, "/home/user/.local/share/myapp"},
+		} {
+			dir, err := tcase.fn()
+			if err != nil {
+				t.Fatal("unexpected error", err)
+			}
+			if dir != tcase.expected {
+				t.Fatalf("dir %q doesn't match expected %q", dir, tcase.expected)
+			}
+		}
+
+		files := paths.ConfigFiles("config.ini")
+		expected := []string{
+			"/etc/myapp/config.ini",
+			"/etc/xdg/myapp/config.ini",
+			"/home/user/.config/myapp/config.ini",
+		}
+		if !slices.Equal(files, expected) {
+			t.Fatalf("files %q doesn't match expected %q", files, expected)
+		}
+	})
+}
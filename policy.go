@@ -0,0 +1,180 @@
+package configue
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/negrel/configue/ini"
+)
+
+var (
+	// ErrWorldWritable is returned if a configuration file is writable by any
+	// user.
+	ErrWorldWritable = errors.New("file is world-writable")
+	// ErrForeignOwner is returned if a configuration file is owned by a user
+	// other than current user and root.
+	ErrForeignOwner = errors.New("file is owned by another user")
+	// ErrSymlink is returned if a configuration file is a symbolic link.
+	ErrSymlink = errors.New("file is a symbolic link")
+	// ErrFileTooLarge is returned if a configuration file exceeds maximum
+	// size.
+	ErrFileTooLarge = errors.New("file is too large")
+)
+
+// FilePolicyError is returned if a configuration file violates a
+// [FilePolicy].
+type FilePolicyError struct {
+	File string
+	Err  error
+}
+
+// Error implements error.
+func (e *FilePolicyError) Error() string {
+	return fmt.Sprintf("file policy violation for %v: %v", e.File, e.Err)
+}
+
+// Unwrap returns underlying error.
+func (e *FilePolicyError) Unwrap() error {
+	return e.Err
+}
+
+// FilePolicy defines checks performed on configuration files, included files
+// and secret files read from the host file system before they're parsed. It is
+// typically used by daemons running as root. Violations are reported as
+// *[FilePolicyError].
+type FilePolicy struct {
+	// RejectWorldWritable refuses files writable by any user.
+	RejectWorldWritable bool
+	// RejectForeignOwner refuses files owned by users other than current user
+	// and root. It is ignored on platforms without file ownership.
+	RejectForeignOwner bool
+	// RejectSymlinks refuses files that are symbolic links.
+	RejectSymlinks bool
+	// MaxSize, if positive, is the maximum size of files in bytes.
+	MaxSize int64
+	// SecretOptions contains paths of options holding secrets. A warning is
+	// printed if one of them is set by a host file readable by group or
+	// others.
+	SecretOptions []string
+}
+
+// open opens file at fpath after checking it complies with policy. A nil
+// policy opens file as is.
+func (p *FilePolicy) open(fpath string) (io.ReadCloser, error) {
+	if p == nil {
+		return os.Open(fpath)
+	}
+
+	var (
+		f   *os.File
+		err error
+	)
+	if p.RejectSymlinks {
+		f, err = openNoFollow(fpath)
+	} else {
+		f, err = os.Open(fpath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Check opened file rather than path so it can't be swapped in between.
+	info, err := f.Stat()
+	if err == nil {
+		err = p.check(fpath, info)
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	if p.MaxSize > 0 {
+		return &limitedFile{f, p.MaxSize}, nil
+	}
+	return f, nil
+}
+
+// opener returns a function opening files using policy or nil if policy is
+// nil.
+func (p *FilePolicy) opener() func(string) (io.ReadCloser, error) {
+	if p == nil {
+		return nil
+	}
+	return p.open
+}
+
+// check checks that file at fpath described by info complies with policy.
+func (p *FilePolicy) check(fpath string, info fs.FileInfo) error {
+	if p.MaxSize > 0 && info.Size() > p.MaxSize {
+		return &FilePolicyError{fpath, fmt.Errorf("%w: %v bytes exceeds %v bytes", ErrFileTooLarge, info.Size(), p.MaxSize)}
+	}
+	if p.RejectWorldWritable && info.Mode().Perm()&0o002 != 0 {
+		return &FilePolicyError{fpath, fmt.Errorf("%w (mode %v)", ErrWorldWritable, info.Mode().Perm())}
+	}
+	if p.RejectForeignOwner {
+		if uid, ok := fileOwner(info); ok && uid != os.Getuid() && uid != 0 {
+			return &FilePolicyError{fpath, fmt.Errorf("%w (uid %v)", ErrForeignOwner, uid)}
+		}
+	}
+	return nil
+}
+
+// warnReadableSecrets prints, to property set output, a warning for each
+// secret option set by a file readable by group or others.
+func (p *FilePolicy) warnReadableSecrets(ps *ini.PropSet) {
+	if p == nil {
+		return
+	}
+
+	for _, path := range p.SecretOptions {
+		loc, ok := ps.Provenance(path)
+		if !ok || loc.Filename == "" {
+			continue
+		}
+		info, err := os.Stat(loc.Filename)
+		if err != nil || info.Mode().Perm()&0o044 == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(ps.Output(), "warning: %v sets secret option %v but is readable by group or others (mode %v)\n",
+			loc.Filename, path, info.Mode().Perm())
+	}
+}
+
+// limitedFile is a file returning an error if more than remaining bytes are
+// read from it. Unlike os.File, it doesn't implement io.WriterTo so reads
+// can't bypass the limit.
+type limitedFile struct {
+	f         *os.File
+	remaining int64
+}
+
+// Read implements io.Reader.
+func (lf *limitedFile) Read(b []byte) (int, error) {
+	if lf.remaining <= 0 {
+		var probe [1]byte
+		n, err := lf.f.Read(probe[:])
+		if n > 0 {
+			return 0, &FilePolicyError{lf.f.Name(), ErrFileTooLarge}
+		}
+		return 0, err
+	}
+
+	if int64(len(b)) > lf.remaining {
+		b = b[:lf.remaining]
+	}
+	n, err := lf.f.Read(b)
+	lf.remaining -= int64(n)
+	return n, err
+}
+
+// Close implements io.Closer.
+func (lf *limitedFile) Close() error {
+	return lf.f.Close()
+}
+
+// Name returns name of the file.
+func (lf *limitedFile) Name() string {
+	return lf.f.Name()
+}
@@ -0,0 +1,41 @@
+//go:build !unix
+
+package configue
+
+import (
+	"io/fs"
+	"os"
+)
+
+// fileOwner always returns false as file ownership isn't supported on this
+// platform.
+func fileOwner(info fs.FileInfo) (int, bool) {
+	return 0, false
+}
+
+// openNoFollow opens file at fpath for reading. It fails with ErrSymlink if
+// file is a symbolic link. As this platform has no O_NOFOLLOW, opened file is
+// compared to the checked one to detect swaps.
+func openNoFollow(fpath string) (*os.File, error) {
+	info, err := os.Lstat(fpath)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		return nil, &FilePolicyError{fpath, ErrSymlink}
+	}
+
+	f, err := os.Open(fpath)
+	if err != nil {
+		return nil, err
+	}
+	opened, err := f.Stat()
+	if err == nil && !os.SameFile(info, opened) {
+		err = &FilePolicyError{fpath, ErrSymlink}
+	}
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
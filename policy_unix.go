@@ -0,0 +1,29 @@
+//go:build unix
+
+package configue
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// fileOwner returns user ID of owner of file described by info.
+func fileOwner(info fs.FileInfo) (int, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(stat.Uid), true
+}
+
+// openNoFollow opens file at fpath for reading. It fails with ErrSymlink if
+// file is a symbolic link.
+func openNoFollow(fpath string) (*os.File, error) {
+	f, err := os.OpenFile(fpath, os.O_RDONLY|syscall.O_NOFOLLOW, 0)
+	if errors.Is(err, syscall.ELOOP) {
+		return nil, &FilePolicyError{fpath, ErrSymlink}
+	}
+	return f, err
+}
@@ -0,0 +1,116 @@
+package configue
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// SignatureSuffix is appended to path of configuration files to get path of
+// their detached signature.
+const SignatureSuffix = ".sig"
+
+var (
+	// ErrUnsignedFile is returned if signature of a configuration file is
+	// missing.
+	ErrUnsignedFile = errors.New("file isn't signed")
+	// ErrInvalidSignature is returned if signature of a configuration file
+	// isn't valid for any trusted key.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrUnnamedFile is returned if a configuration file without name, such
+	// as standard input, must be verified.
+	ErrUnnamedFile = errors.New("verification requires a named file")
+)
+
+// SignatureError is returned if signature verification of a configuration
+// file fails.
+type SignatureError struct {
+	File string
+	Err  error
+}
+
+// Error implements error.
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("signature verification of %v failed: %v", e.File, e.Err)
+}
+
+// Unwrap returns underlying error.
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// ParsePublicKey parses a base64 encoded ed25519 public key.
+func ParsePublicKey(b64 string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(b64))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid ed25519 public key size: %v bytes, expected %v", len(key), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// ReadPublicKeyFile reads a base64 encoded ed25519 public key from file at
+// path.
+func ReadPublicKeyFile(path string) (ed25519.PublicKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(string(content))
+}
+
+// VerifySignatures enables verification of configuration files in backends
+// supporting it (e.g. [Ini]). Files must have a detached ed25519 signature
+// (path + [SignatureSuffix]) valid for one of trusted keys, otherwise they're
+// refused before any of their options is applied. Files without name, such as
+// standard input, are always refused.
+func (f *Figue) VerifySignatures(keys ...ed25519.PublicKey) {
+	for _, b := range f.backends {
+		if b, ok := b.(interface{ VerifySignatures(...ed25519.PublicKey) }); ok {
+			b.VerifySignatures(keys...)
+		}
+	}
+}
+
+// verifySignature verifies that content of file name has a detached
+// signature, read from fsys or host file system if nil, valid for one of keys.
+func verifySignature(fsys fs.FS, keys []ed25519.PublicKey, name string, content []byte) error {
+	// Signature path is derived from name.
+	if name == "" || name == stdinName {
+		return &SignatureError{name, ErrUnnamedFile}
+	}
+
+	var (
+		b64 []byte
+		err error
+	)
+	if fsys != nil {
+		b64, err = fs.ReadFile(fsys, name+SignatureSuffix)
+	} else {
+		b64, err = os.ReadFile(name + SignatureSuffix)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return &SignatureError{name, ErrUnsignedFile}
+	}
+	if err != nil {
+		return &SignatureError{name, err}
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(b64)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return &SignatureError{name, fmt.Errorf("%w: malformed signature file", ErrInvalidSignature)}
+	}
+	for _, key := range keys {
+		if ed25519.Verify(key, content, sig) {
+			return nil
+		}
+	}
+
+	return &SignatureError{name, ErrInvalidSignature}
+}